@@ -12,8 +12,11 @@ import (
 	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
 	"github.com/vishenosik/ai-cherry-bro/internal/agent/core"
 	"github.com/vishenosik/ai-cherry-bro/internal/api"
+	"github.com/vishenosik/ai-cherry-bro/internal/config"
 	_context "github.com/vishenosik/ai-cherry-bro/internal/context"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
 	"github.com/vishenosik/ai-cherry-bro/internal/security"
+	"github.com/vishenosik/ai-cherry-bro/internal/store/local"
 	"github.com/vishenosik/ai-cherry-bro/internal/usecase"
 	"github.com/vishenosik/gocherry"
 
@@ -26,6 +29,9 @@ func main() {
 
 	log := logs.SetupLogger().With(logs.AppComponent("main"))
 
+	// Флаги gocherry и приложения разбираются одним набором flag.CommandLine
+	printConfig := flag.Bool("print-config", false, "print the settings with secrets redacted and exit")
+
 	gocherry.Flags(os.Stdout, os.Args[1:],
 		gocherry.AppFlags(os.Stdout),
 		gocherry.ConfigFlags(os.Stdout),
@@ -33,9 +39,24 @@ func main() {
 
 	flag.Parse()
 
+	configFile := config.FileFromFlags(flag.CommandLine)
+
+	if *printConfig {
+		conf, err := config.Load(configFile)
+		if printErr := conf.Print(os.Stdout); printErr != nil {
+			log.Error("failed to print config", logs.Error(printErr))
+			os.Exit(1)
+		}
+		if err != nil {
+			log.Error("invalid config", logs.Error(err))
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx := context.Background()
 
-	app, err := NewApp(ctx)
+	app, err := NewApp(ctx, configFile)
 	if err != nil {
 		log.Error("failed to init app", logs.Error(err))
 		os.Exit(1)
@@ -57,23 +78,70 @@ func main() {
 	app.Stop(stopctx)
 }
 
-func NewApp(ctx context.Context) (*gocherry.App, error) {
+func NewApp(ctx context.Context, configFile string) (*gocherry.App, error) {
+
+	// CONFIG
+
+	conf, err := config.Load(configFile)
+	if err != nil {
+		return nil, err
+	}
 
 	// STORES
 
-	// localStore := local.NewFileStore()
+	localStore, err := local.NewFileStore(conf.Store)
+	if err != nil {
+		return nil, err
+	}
+
+	profileStore, err := local.NewProfileStore(conf.Store)
+	if err != nil {
+		return nil, err
+	}
 
 	// USECASES
 
-	taskProvider := usecase.NewTaskProvider(nil)
+	taskProvider := usecase.NewTaskProvider(localStore)
+
+	// Возвращаем в очередь задачи, не завершённые до перезапуска
+	if err := taskProvider.Recover(ctx); err != nil {
+		return nil, err
+	}
+
+	// SECURITY
+
+	approvalBroker := security.NewBroker(conf.Security.ApprovalTimeout, taskProvider)
+
+	var approver security.Approver = approvalBroker
+	if conf.Security.ApprovalMode == security.ApprovalModeStdin {
+		approver = security.NewStdinApprover()
+	}
+
+	policy, err := security.LoadPolicy(conf.Security.PolicyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без ключа агент не получает учётных данных и просит пользователя войти сам
+	var (
+		vault   *security.Vault
+		secrets browser.Secrets
+	)
+	if conf.Security.VaultKey != "" {
+		vault, err = security.OpenVault(conf.Security.VaultFile, conf.Security.VaultKey)
+		if err != nil {
+			return nil, err
+		}
+		secrets = vault
+	}
 
 	// API
 
-	bsApi := api.NewBrowserServiceApi(taskProvider)
+	bsApi := api.NewBrowserServiceApi(taskProvider, approvalBroker, profileStore)
 
 	// AGENTS
 
-	browserAgent, err := browser.NewBrowserAgent()
+	browserAgent, err := browser.NewBrowserAgent(conf.Browser, secrets, profileStore)
 	if err != nil {
 		return nil, err
 	}
@@ -90,19 +158,32 @@ func NewApp(ctx context.Context) (*gocherry.App, error) {
 		return nil, err
 	}
 
-	// TODO
-
-	aiClient := ai.NewClient()
-	contextManager := _context.NewManager(8000) // 8K токенов контекста
-	securityLayer := security.NewLayer()
+	aiClient, err := ai.NewProviderClient(conf.AI)
+	if err != nil {
+		return nil, err
+	}
+	// Каждая задача получает свою историю
+	newContextManager := func(task entity.PoolTask) core.ContextManager {
+		model := task.Options.Model
+		if model == "" {
+			model = aiClient.Model()
+		}
+		return _context.NewManager(conf.Context, _context.NewTokenCounter(model))
+	}
+	securityLayer := security.NewLayer(policy, approver,
+		security.WithInjectionApproval(conf.Security.InjectionApproval),
+		security.WithVault(vault),
+	)
 
 	// CORE
 
 	orch, err := core.NewOrchestrator(
+		conf.Agent,
 		browserAgent,
 		aiClient,
-		contextManager,
+		newContextManager,
 		securityLayer,
+		taskProvider,
 		taskProvider.TasksChan(),
 	)
 	if err != nil {
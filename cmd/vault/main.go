@@ -0,0 +1,100 @@
+// vault управляет зашифрованным хранилищем учётных данных агента.
+//
+//	vault set -domain github.com github password   # значение читается из stdin
+//	vault list
+//	vault delete github
+//	vault -config settings.yaml list             # файл настроек тот же, что у агента
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/config"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+)
+
+type domainsFlag []string
+
+func (d *domainsFlag) String() string {
+	return strings.Join(*d, ",")
+}
+
+func (d *domainsFlag) Set(value string) error {
+	*d = append(*d, value)
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "vault:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	global := flag.NewFlagSet("vault", flag.ContinueOnError)
+	global.String(config.FileFlag, "", "settings file shared with the agent; without it "+config.DefaultFile+" is read if it exists")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+
+	// Хранилище берётся из тех же настроек, что и у агента
+	conf, err := config.Load(config.FileFromFlags(global))
+	if err != nil {
+		return err
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		return usage()
+	}
+
+	vault, err := security.OpenVault(conf.Security.VaultFile, conf.Security.VaultKey)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "set":
+		var domains domainsFlag
+		flags := flag.NewFlagSet("set", flag.ContinueOnError)
+		flags.Var(&domains, "domain", "site the credential may be typed on, repeatable, *.example.com includes subdomains")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 2 {
+			return usage()
+		}
+
+		// Значение не передаётся аргументом, чтобы не попасть в историю shell
+		fmt.Fprintf(os.Stderr, "value for %s.%s: ", flags.Arg(0), flags.Arg(1))
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && value == "" {
+			return fmt.Errorf("failed to read value: %v", err)
+		}
+		return vault.Set(flags.Arg(0), flags.Arg(1), strings.TrimRight(value, "\r\n"), domains)
+
+	case "list":
+		for _, ref := range vault.Refs() {
+			fmt.Printf("%s\t%s\n", ref.Placeholder, strings.Join(ref.Domains, ","))
+		}
+		return nil
+
+	case "delete":
+		if len(args) != 2 {
+			return usage()
+		}
+		return vault.Delete(args[1])
+
+	default:
+		return usage()
+	}
+}
+
+func usage() error {
+	return fmt.Errorf("usage: vault [-config <file>] set -domain <domain>... <name> <field> | list | delete <name>")
+}
@@ -19,7 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	BrowserTaskService_NewTask_FullMethodName = "/browser_task.v1.BrowserTaskService/NewTask"
+	BrowserTaskService_NewTask_FullMethodName              = "/browser_task.v1.BrowserTaskService/NewTask"
+	BrowserTaskService_GetTask_FullMethodName              = "/browser_task.v1.BrowserTaskService/GetTask"
+	BrowserTaskService_WatchTask_FullMethodName            = "/browser_task.v1.BrowserTaskService/WatchTask"
+	BrowserTaskService_CancelTask_FullMethodName           = "/browser_task.v1.BrowserTaskService/CancelTask"
+	BrowserTaskService_ListPendingApprovals_FullMethodName = "/browser_task.v1.BrowserTaskService/ListPendingApprovals"
+	BrowserTaskService_ResolveApproval_FullMethodName      = "/browser_task.v1.BrowserTaskService/ResolveApproval"
+	BrowserTaskService_ListProfiles_FullMethodName         = "/browser_task.v1.BrowserTaskService/ListProfiles"
+	BrowserTaskService_ExportProfile_FullMethodName        = "/browser_task.v1.BrowserTaskService/ExportProfile"
+	BrowserTaskService_ImportProfile_FullMethodName        = "/browser_task.v1.BrowserTaskService/ImportProfile"
+	BrowserTaskService_WipeProfile_FullMethodName          = "/browser_task.v1.BrowserTaskService/WipeProfile"
 )
 
 // BrowserTaskServiceClient is the client API for BrowserTaskService service.
@@ -27,6 +36,20 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BrowserTaskServiceClient interface {
 	NewTask(ctx context.Context, in *NewTaskReq, opts ...grpc.CallOption) (*NewTaskResp, error)
+	GetTask(ctx context.Context, in *GetTaskReq, opts ...grpc.CallOption) (*GetTaskResp, error)
+	// Streams task events. Events emitted before the call are replayed first.
+	WatchTask(ctx context.Context, in *WatchTaskReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error)
+	CancelTask(ctx context.Context, in *CancelTaskReq, opts ...grpc.CallOption) (*CancelTaskResp, error)
+	ListPendingApprovals(ctx context.Context, in *ListPendingApprovalsReq, opts ...grpc.CallOption) (*ListPendingApprovalsResp, error)
+	ResolveApproval(ctx context.Context, in *ResolveApprovalReq, opts ...grpc.CallOption) (*ResolveApprovalResp, error)
+	// Browser profiles keep cookies and localStorage between tasks and restarts.
+	ListProfiles(ctx context.Context, in *ListProfilesReq, opts ...grpc.CallOption) (*ListProfilesResp, error)
+	// Exports the Playwright storage state of a profile, it contains session cookies.
+	ExportProfile(ctx context.Context, in *ExportProfileReq, opts ...grpc.CallOption) (*ExportProfileResp, error)
+	// Creates or replaces a profile from a Playwright storage state.
+	ImportProfile(ctx context.Context, in *ImportProfileReq, opts ...grpc.CallOption) (*ImportProfileResp, error)
+	// Deletes a profile with all its sessions.
+	WipeProfile(ctx context.Context, in *WipeProfileReq, opts ...grpc.CallOption) (*WipeProfileResp, error)
 }
 
 type browserTaskServiceClient struct {
@@ -47,11 +70,124 @@ func (c *browserTaskServiceClient) NewTask(ctx context.Context, in *NewTaskReq,
 	return out, nil
 }
 
+func (c *browserTaskServiceClient) GetTask(ctx context.Context, in *GetTaskReq, opts ...grpc.CallOption) (*GetTaskResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetTaskResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_GetTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) WatchTask(ctx context.Context, in *WatchTaskReq, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TaskEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BrowserTaskService_ServiceDesc.Streams[0], BrowserTaskService_WatchTask_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchTaskReq, TaskEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserTaskService_WatchTaskClient = grpc.ServerStreamingClient[TaskEvent]
+
+func (c *browserTaskServiceClient) CancelTask(ctx context.Context, in *CancelTaskReq, opts ...grpc.CallOption) (*CancelTaskResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelTaskResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_CancelTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) ListPendingApprovals(ctx context.Context, in *ListPendingApprovalsReq, opts ...grpc.CallOption) (*ListPendingApprovalsResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPendingApprovalsResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_ListPendingApprovals_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) ResolveApproval(ctx context.Context, in *ResolveApprovalReq, opts ...grpc.CallOption) (*ResolveApprovalResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResolveApprovalResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_ResolveApproval_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) ListProfiles(ctx context.Context, in *ListProfilesReq, opts ...grpc.CallOption) (*ListProfilesResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProfilesResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_ListProfiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) ExportProfile(ctx context.Context, in *ExportProfileReq, opts ...grpc.CallOption) (*ExportProfileResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportProfileResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_ExportProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) ImportProfile(ctx context.Context, in *ImportProfileReq, opts ...grpc.CallOption) (*ImportProfileResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportProfileResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_ImportProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserTaskServiceClient) WipeProfile(ctx context.Context, in *WipeProfileReq, opts ...grpc.CallOption) (*WipeProfileResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WipeProfileResp)
+	err := c.cc.Invoke(ctx, BrowserTaskService_WipeProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // BrowserTaskServiceServer is the server API for BrowserTaskService service.
 // All implementations must embed UnimplementedBrowserTaskServiceServer
 // for forward compatibility.
 type BrowserTaskServiceServer interface {
 	NewTask(context.Context, *NewTaskReq) (*NewTaskResp, error)
+	GetTask(context.Context, *GetTaskReq) (*GetTaskResp, error)
+	// Streams task events. Events emitted before the call are replayed first.
+	WatchTask(*WatchTaskReq, grpc.ServerStreamingServer[TaskEvent]) error
+	CancelTask(context.Context, *CancelTaskReq) (*CancelTaskResp, error)
+	ListPendingApprovals(context.Context, *ListPendingApprovalsReq) (*ListPendingApprovalsResp, error)
+	ResolveApproval(context.Context, *ResolveApprovalReq) (*ResolveApprovalResp, error)
+	// Browser profiles keep cookies and localStorage between tasks and restarts.
+	ListProfiles(context.Context, *ListProfilesReq) (*ListProfilesResp, error)
+	// Exports the Playwright storage state of a profile, it contains session cookies.
+	ExportProfile(context.Context, *ExportProfileReq) (*ExportProfileResp, error)
+	// Creates or replaces a profile from a Playwright storage state.
+	ImportProfile(context.Context, *ImportProfileReq) (*ImportProfileResp, error)
+	// Deletes a profile with all its sessions.
+	WipeProfile(context.Context, *WipeProfileReq) (*WipeProfileResp, error)
 	mustEmbedUnimplementedBrowserTaskServiceServer()
 }
 
@@ -65,6 +201,33 @@ type UnimplementedBrowserTaskServiceServer struct{}
 func (UnimplementedBrowserTaskServiceServer) NewTask(context.Context, *NewTaskReq) (*NewTaskResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NewTask not implemented")
 }
+func (UnimplementedBrowserTaskServiceServer) GetTask(context.Context, *GetTaskReq) (*GetTaskResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTask not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) WatchTask(*WatchTaskReq, grpc.ServerStreamingServer[TaskEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTask not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) CancelTask(context.Context, *CancelTaskReq) (*CancelTaskResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelTask not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) ListPendingApprovals(context.Context, *ListPendingApprovalsReq) (*ListPendingApprovalsResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingApprovals not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) ResolveApproval(context.Context, *ResolveApprovalReq) (*ResolveApprovalResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveApproval not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) ListProfiles(context.Context, *ListProfilesReq) (*ListProfilesResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProfiles not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) ExportProfile(context.Context, *ExportProfileReq) (*ExportProfileResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportProfile not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) ImportProfile(context.Context, *ImportProfileReq) (*ImportProfileResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportProfile not implemented")
+}
+func (UnimplementedBrowserTaskServiceServer) WipeProfile(context.Context, *WipeProfileReq) (*WipeProfileResp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WipeProfile not implemented")
+}
 func (UnimplementedBrowserTaskServiceServer) mustEmbedUnimplementedBrowserTaskServiceServer() {}
 func (UnimplementedBrowserTaskServiceServer) testEmbeddedByValue()                            {}
 
@@ -104,6 +267,161 @@ func _BrowserTaskService_NewTask_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BrowserTaskService_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTaskReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_GetTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).GetTask(ctx, req.(*GetTaskReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_WatchTask_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchTaskReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrowserTaskServiceServer).WatchTask(m, &grpc.GenericServerStream[WatchTaskReq, TaskEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserTaskService_WatchTaskServer = grpc.ServerStreamingServer[TaskEvent]
+
+func _BrowserTaskService_CancelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTaskReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).CancelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_CancelTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).CancelTask(ctx, req.(*CancelTaskReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_ListPendingApprovals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingApprovalsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).ListPendingApprovals(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_ListPendingApprovals_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).ListPendingApprovals(ctx, req.(*ListPendingApprovalsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_ResolveApproval_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResolveApprovalReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).ResolveApproval(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_ResolveApproval_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).ResolveApproval(ctx, req.(*ResolveApprovalReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_ListProfiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProfilesReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).ListProfiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_ListProfiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).ListProfiles(ctx, req.(*ListProfilesReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_ExportProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportProfileReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).ExportProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_ExportProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).ExportProfile(ctx, req.(*ExportProfileReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_ImportProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportProfileReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).ImportProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_ImportProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).ImportProfile(ctx, req.(*ImportProfileReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserTaskService_WipeProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WipeProfileReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserTaskServiceServer).WipeProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserTaskService_WipeProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserTaskServiceServer).WipeProfile(ctx, req.(*WipeProfileReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // BrowserTaskService_ServiceDesc is the grpc.ServiceDesc for BrowserTaskService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,7 +433,45 @@ var BrowserTaskService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NewTask",
 			Handler:    _BrowserTaskService_NewTask_Handler,
 		},
+		{
+			MethodName: "GetTask",
+			Handler:    _BrowserTaskService_GetTask_Handler,
+		},
+		{
+			MethodName: "CancelTask",
+			Handler:    _BrowserTaskService_CancelTask_Handler,
+		},
+		{
+			MethodName: "ListPendingApprovals",
+			Handler:    _BrowserTaskService_ListPendingApprovals_Handler,
+		},
+		{
+			MethodName: "ResolveApproval",
+			Handler:    _BrowserTaskService_ResolveApproval_Handler,
+		},
+		{
+			MethodName: "ListProfiles",
+			Handler:    _BrowserTaskService_ListProfiles_Handler,
+		},
+		{
+			MethodName: "ExportProfile",
+			Handler:    _BrowserTaskService_ExportProfile_Handler,
+		},
+		{
+			MethodName: "ImportProfile",
+			Handler:    _BrowserTaskService_ImportProfile_Handler,
+		},
+		{
+			MethodName: "WipeProfile",
+			Handler:    _BrowserTaskService_WipeProfile_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTask",
+			Handler:       _BrowserTaskService_WatchTask_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "browser_task.proto",
 }
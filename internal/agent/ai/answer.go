@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+)
+
+type answerSchemaKey struct{}
+
+// WithAnswerSchema задаёт JSON схему ответа задачи: аргумент data у extract и complete
+// объявляется модели с этой схемой и проверяется по ней. complete без data завершает
+// задачу с данными последнего extract, без них ответ не принимается оркестратором.
+func WithAnswerSchema(ctx context.Context, schema jsonschema.Schema) context.Context {
+	if schema == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, answerSchemaKey{}, schema)
+}
+
+// toolsFromContext инструменты с учётом схемы ответа задачи
+func toolsFromContext(ctx context.Context) []Tool {
+	schema, ok := ctx.Value(answerSchemaKey{}).(jsonschema.Schema)
+	if !ok {
+		return Tools
+	}
+
+	tools := slices.Clone(Tools)
+	for i, tool := range tools {
+		switch tool.Name {
+		case "extract", "complete":
+			tools[i].Parameters = withData(tool.Parameters, schema)
+		}
+	}
+	return tools
+}
+
+// withData подставляет схему в свойство data, не меняя исходные параметры
+func withData(params, schema jsonschema.Schema) jsonschema.Schema {
+	params = maps.Clone(params)
+
+	props, _ := params["properties"].(map[string]any)
+	props = maps.Clone(props)
+	props["data"] = schema
+	params["properties"] = props
+	return params
+}
@@ -0,0 +1,212 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+)
+
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient клиент для Anthropic Messages API
+type AnthropicClient struct {
+	apiKey     string
+	baseURL    string
+	model      string
+	params     Params
+	httpClient *http.Client
+	vision     visionFallback
+}
+
+type AnthropicRequest struct {
+	Model       string               `json:"model"`
+	System      string               `json:"system,omitempty"`
+	Messages    []AnthropicMessage   `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	Tools       []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *AnthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// AnthropicMessage сообщение запроса, Content - строка или массив AnthropicContentBlock
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type AnthropicContentBlock struct {
+	Type   string                `json:"type"`
+	Text   string                `json:"text,omitempty"`
+	Source *AnthropicImageSource `json:"source,omitempty"`
+}
+
+type AnthropicImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+func anthropicMessage(msg entity.AiMessage) AnthropicMessage {
+	if len(msg.Images) == 0 {
+		return AnthropicMessage{Role: msg.Role, Content: msg.Content}
+	}
+
+	blocks := []AnthropicContentBlock{{Type: "text", Text: msg.Content}}
+	for _, image := range msg.Images {
+		blocks = append(blocks, AnthropicContentBlock{
+			Type: "image",
+			Source: &AnthropicImageSource{
+				Type:      "base64",
+				MediaType: image.MediaType,
+				Data:      base64.StdEncoding.EncodeToString(image.Data),
+			},
+		})
+	}
+	return AnthropicMessage{Role: msg.Role, Content: blocks}
+}
+
+type AnthropicTool struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	InputSchema jsonschema.Schema `json:"input_schema"`
+}
+
+type AnthropicToolChoice struct {
+	Type string `json:"type"`
+}
+
+type AnthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func anthropicTools(tools []Tool) []AnthropicTool {
+	result := make([]AnthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, AnthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+	return result
+}
+
+func NewAnthropicClient(baseURL, apiKey, model string, opts ...ClientOption) *AnthropicClient {
+	params := clientParams(opts)
+	return &AnthropicClient{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		model:   model,
+		params:  params,
+		httpClient: &http.Client{
+			Timeout: params.Timeout,
+		},
+		vision: newVisionFallback(),
+	}
+}
+
+func (c *AnthropicClient) Model() string {
+	return c.model
+}
+
+func (c *AnthropicClient) Call(ctx context.Context, messages []entity.AiMessage) (*entity.AiResponse, error) {
+	tools := toolsFromContext(ctx)
+	return decide(messages, tools, func(messages []entity.AiMessage) (toolCall, error) {
+		return c.complete(ctx, messages, tools)
+	})
+}
+
+func (c *AnthropicClient) complete(ctx context.Context, messages []entity.AiMessage, tools []Tool) (toolCall, error) {
+	model := modelFromContext(ctx, c.model)
+	messages = c.vision.prepare(model, messages)
+
+	// Системный промпт в Messages API передается отдельным полем
+	var system []string
+	chat := make([]AnthropicMessage, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		chat = append(chat, anthropicMessage(msg))
+	}
+
+	request := AnthropicRequest{
+		Model:       model,
+		System:      strings.Join(system, "\n\n"),
+		Messages:    chat,
+		MaxTokens:   c.params.MaxTokens,
+		Temperature: &c.params.Temperature,
+		Tools:       anthropicTools(tools),
+		ToolChoice:  &AnthropicToolChoice{Type: "any"},
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return toolCall{}, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/messages", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return toolCall{}, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return toolCall{}, fmt.Errorf("API request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return toolCall{}, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if c.vision.rejected(model, resp.StatusCode, body, messages) {
+			return c.complete(ctx, withoutImages(messages), tools)
+		}
+		return toolCall{}, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var msgResp AnthropicResponse
+	if err := json.Unmarshal(body, &msgResp); err != nil {
+		return toolCall{}, fmt.Errorf("failed to parse response: %v", err)
+	}
+
+	var content strings.Builder
+	for _, block := range msgResp.Content {
+		switch block.Type {
+		case "tool_use":
+			return toolCall{
+				Name:      block.Name,
+				Arguments: block.Input,
+			}, nil
+		case "text":
+			content.WriteString(block.Text)
+		}
+	}
+
+	return toolCall{Content: content.String()}, nil
+}
@@ -2,164 +2,366 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"strings"
 	"time"
 
-	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+	"github.com/vishenosik/gocherry/pkg/logs"
 )
 
+// Caller общий интерфейс клиентов LLM провайдеров
+type Caller interface {
+	Call(ctx context.Context, messages []entity.AiMessage) (*entity.AiResponse, error)
+	// Model модель по умолчанию, если задача не переопределяет её
+	Model() string
+}
+
+// Client клиент для OpenAI и OpenAI-совместимых API (DeepSeek, vLLM, LM Studio, Ollama)
 type Client struct {
 	apiKey     string
 	baseURL    string
 	model      string
+	params     Params
 	httpClient *http.Client
+	vision     visionFallback
 }
 
 type ChatRequest struct {
-	Model       string             `json:"model"`
-	Messages    []entity.AiMessage `json:"messages"`
-	MaxTokens   int                `json:"max_tokens,omitempty"`
-	Temperature float64            `json:"temperature,omitempty"`
+	Model       string               `json:"model"`
+	Messages    []ChatRequestMessage `json:"messages"`
+	MaxTokens   int                  `json:"max_tokens,omitempty"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	Tools       []ChatTool           `json:"tools,omitempty"`
+	ToolChoice  string               `json:"tool_choice,omitempty"`
+}
+
+// ChatRequestMessage сообщение запроса, Content - строка или массив ChatContentPart
+type ChatRequestMessage struct {
+	Role    string `json:"role"`
+	Content any    `json:"content"`
+}
+
+type ChatContentPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *ChatImageURL `json:"image_url,omitempty"`
+}
+
+type ChatImageURL struct {
+	URL string `json:"url"`
+}
+
+func chatMessages(messages []entity.AiMessage) []ChatRequestMessage {
+	chat := make([]ChatRequestMessage, 0, len(messages))
+	for _, msg := range messages {
+		if len(msg.Images) == 0 {
+			chat = append(chat, ChatRequestMessage{Role: msg.Role, Content: msg.Content})
+			continue
+		}
+
+		parts := []ChatContentPart{{Type: "text", Text: msg.Content}}
+		for _, image := range msg.Images {
+			parts = append(parts, ChatContentPart{
+				Type:     "image_url",
+				ImageURL: &ChatImageURL{URL: dataURL(image)},
+			})
+		}
+		chat = append(chat, ChatRequestMessage{Role: msg.Role, Content: parts})
+	}
+	return chat
+}
+
+type ChatTool struct {
+	Type     string       `json:"type"`
+	Function ChatFunction `json:"function"`
+}
+
+type ChatFunction struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Parameters  jsonschema.Schema `json:"parameters"`
 }
 
 type ChatResponse struct {
 	Choices []struct {
-		Message entity.AiMessage `json:"message"`
+		Message ChatMessage `json:"message"`
 	} `json:"choices"`
 	Error struct {
 		Message string `json:"message"`
 	} `json:"error"`
 }
 
+type ChatMessage struct {
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	ToolCalls []struct {
+		Function struct {
+			Name      string `json:"name"`
+			Arguments string `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+}
+
+func chatTools(tools []Tool) []ChatTool {
+	result := make([]ChatTool, 0, len(tools))
+	for _, tool := range tools {
+		result = append(result, ChatTool{
+			Type: "function",
+			Function: ChatFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return result
+}
+
 type Provider string
 
 const (
-	ProviderDeepSeek Provider = "deepseek"
-	ProviderOpenAI   Provider = "openai"
-	ProviderMock     Provider = "mock"
+	ProviderDeepSeek         Provider = "deepseek"
+	ProviderOpenAI           Provider = "openai"
+	ProviderOpenAICompatible Provider = "openai_compatible"
+	ProviderOllama           Provider = "ollama"
+	ProviderAnthropic        Provider = "anthropic"
+	ProviderMock             Provider = "mock"
 )
 
+type providerDefaults struct {
+	baseURL string
+	model   string
+}
+
+var defaults = map[Provider]providerDefaults{
+	ProviderOpenAI:           {baseURL: "https://api.openai.com/v1", model: "gpt-4"},
+	ProviderDeepSeek:         {baseURL: "https://api.deepseek.com/v1", model: "deepseek-chat"},
+	ProviderOllama:           {baseURL: "http://localhost:11434/v1", model: "llama3.1"},
+	ProviderAnthropic:        {baseURL: "https://api.anthropic.com/v1", model: "claude-3-5-sonnet-latest"},
+	ProviderOpenAICompatible: {},
+}
+
 type Config struct {
+	Provider Provider `env:"AI_PROVIDER" env-default:"openai"`
+	ApiKey   string   `env:"AI_API_KEY"`
+	// BaseURL и Model по умолчанию берутся из настроек провайдера
+	BaseURL string `env:"AI_BASE_URL"`
+	Model   string `env:"AI_MODEL"`
+
+	// OpenAiApiKey используется, если AI_API_KEY не задан
 	OpenAiApiKey string `env:"OPENAI_API_KEY"`
+
+	// MockScript путь к сценарию для провайдера mock
+	MockScript string `env:"AI_MOCK_SCRIPT"`
+
+	Params Params
 }
 
-// NewClient создает клиент на основе доступных API ключей
-func NewClient() *Client {
+// Params параметры запросов к модели
+type Params struct {
+	MaxTokens   int           `env:"AI_MAX_TOKENS" env-default:"1000"`
+	Temperature float64       `env:"AI_TEMPERATURE" env-default:"0.1"`
+	Timeout     time.Duration `env:"AI_TIMEOUT" env-default:"30s"`
+}
 
-	var conf Config
-	err := cleanenv.ReadConfig(".env", &conf)
-	if err != nil {
-		log.Fatalf("Failed to read config: %v", err)
+// defaultParams параметры клиентов, созданных без WithParams
+var defaultParams = Params{
+	MaxTokens:   1000,
+	Temperature: 0.1,
+	Timeout:     30 * time.Second,
+}
+
+func (p Params) Validate() error {
+	if p.MaxTokens < 1 {
+		return fmt.Errorf("invalid max tokens: %d", p.MaxTokens)
+	}
+	if p.Temperature < 0 || p.Temperature > 2 {
+		return fmt.Errorf("invalid temperature: %v", p.Temperature)
 	}
+	if p.Timeout <= 0 {
+		return fmt.Errorf("invalid timeout: %v", p.Timeout)
+	}
+	return nil
+}
+
+// ClientOption настройка клиента провайдера
+type ClientOption func(*Params)
 
-	fmt.Println("✅ Using OpenAI API")
-	return NewOpenAIClient(conf.OpenAiApiKey, "gpt-4")
+// WithParams задаёт параметры запросов вместо параметров по умолчанию
+func WithParams(params Params) ClientOption {
+	return func(p *Params) {
+		*p = params
+	}
+}
+
+func clientParams(opts []ClientOption) Params {
+	params := defaultParams
+	for _, opt := range opts {
+		opt(&params)
+	}
+	return params
+}
+
+func (conf Config) Validate() error {
+	if conf.Provider == ProviderMock {
+		if conf.MockScript == "" {
+			return fmt.Errorf("ai provider mock requires AI_MOCK_SCRIPT")
+		}
+		return nil
+	}
+
+	def, ok := defaults[conf.Provider]
+	if !ok {
+		return fmt.Errorf("unsupported ai provider: %q", conf.Provider)
+	}
+	if (conf.BaseURL == "" && def.baseURL == "") || (conf.Model == "" && def.model == "") {
+		return fmt.Errorf("ai provider %q requires AI_BASE_URL and AI_MODEL", conf.Provider)
+	}
+	return conf.Params.Validate()
+}
+
+// NewProviderClient создает клиент выбранного в конфиге провайдера
+func NewProviderClient(conf Config) (Caller, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	log := logs.SetupLogger().With(logs.AppComponent("ai.provider"))
+
+	if conf.Provider == ProviderMock {
+		script, err := LoadMockScript(conf.MockScript)
+		if err != nil {
+			return nil, err
+		}
+		log.Info("ai provider selected", slog.String("provider", string(conf.Provider)), slog.String("script", conf.MockScript))
+		return NewMockClient(script)
+	}
+
+	def := defaults[conf.Provider]
+
+	baseURL := conf.BaseURL
+	if baseURL == "" {
+		baseURL = def.baseURL
+	}
+	model := conf.Model
+	if model == "" {
+		model = def.model
+	}
+	apiKey := conf.ApiKey
+	if apiKey == "" {
+		apiKey = conf.OpenAiApiKey
+	}
+
+	log.Info("ai provider selected", slog.String("provider", string(conf.Provider)), slog.String("model", model))
+
+	switch conf.Provider {
+	case ProviderAnthropic:
+		return NewAnthropicClient(baseURL, apiKey, model, WithParams(conf.Params)), nil
+	default:
+		return NewOpenAICompatibleClient(baseURL, apiKey, model, WithParams(conf.Params)), nil
+	}
 }
 
 // NewOpenAIClient оригинальная реализация для OpenAI
-func NewOpenAIClient(apiKey, model string) *Client {
+func NewOpenAIClient(apiKey, model string, opts ...ClientOption) *Client {
+	return NewOpenAICompatibleClient(defaults[ProviderOpenAI].baseURL, apiKey, model, opts...)
+}
+
+// NewOpenAICompatibleClient клиент для любого API с /chat/completions
+func NewOpenAICompatibleClient(baseURL, apiKey, model string, opts ...ClientOption) *Client {
+	params := clientParams(opts)
 	return &Client{
 		apiKey:  apiKey,
-		baseURL: "https://api.openai.com/v1",
+		baseURL: baseURL,
 		model:   model,
+		params:  params,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: params.Timeout,
 		},
+		vision: newVisionFallback(),
 	}
 }
 
-func (c *Client) Call(messages []entity.AiMessage) (*entity.AiResponse, error) {
+func (c *Client) Model() string {
+	return c.model
+}
+
+func (c *Client) Call(ctx context.Context, messages []entity.AiMessage) (*entity.AiResponse, error) {
+	tools := toolsFromContext(ctx)
+	return decide(messages, tools, func(messages []entity.AiMessage) (toolCall, error) {
+		return c.complete(ctx, messages, tools)
+	})
+}
+
+func (c *Client) complete(ctx context.Context, messages []entity.AiMessage, tools []Tool) (toolCall, error) {
+	model := modelFromContext(ctx, c.model)
+	messages = c.vision.prepare(model, messages)
+
 	request := ChatRequest{
-		Model:       c.model,
-		Messages:    messages,
-		MaxTokens:   1000,
-		Temperature: 0.1,
+		Model:       model,
+		Messages:    chatMessages(messages),
+		MaxTokens:   c.params.MaxTokens,
+		Temperature: &c.params.Temperature,
+		Tools:       chatTools(tools),
+		ToolChoice:  "required",
 	}
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %v", err)
+		return toolCall{}, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return toolCall{}, fmt.Errorf("failed to create request: %v", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("API request failed: %v", err)
+		return toolCall{}, fmt.Errorf("API request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %v", err)
+		return toolCall{}, fmt.Errorf("failed to read response: %v", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		if c.vision.rejected(model, resp.StatusCode, body, messages) {
+			return c.complete(ctx, withoutImages(messages), tools)
+		}
+		return toolCall{}, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
 	}
 
 	var chatResp ChatResponse
 	if err := json.Unmarshal(body, &chatResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %v", err)
+		return toolCall{}, fmt.Errorf("failed to parse response: %v", err)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in response")
-	}
-
-	// Парсинг структурированного ответа
-	var aiResp entity.AiResponse
-	content := chatResp.Choices[0].Message.Content
-
-	// Пытаемся распарсить JSON ответ
-	if strings.Contains(content, "{") && strings.Contains(content, "}") {
-		jsonStart := strings.Index(content, "{")
-		jsonEnd := strings.LastIndex(content, "}") + 1
-		if jsonEnd > jsonStart {
-			jsonStr := content[jsonStart:jsonEnd]
-			if err := json.Unmarshal([]byte(jsonStr), &aiResp); err == nil {
-				return &aiResp, nil
-			}
-		}
+		return toolCall{}, fmt.Errorf("no choices in response")
 	}
 
-	// Fallback: анализируем текстовый ответ
-	aiResp = parseTextResponse(content)
-	return &aiResp, nil
-}
-
-func parseTextResponse(text string) entity.AiResponse {
-	// Упрощенный парсинг текстового ответа
-	resp := entity.AiResponse{
-		Reasoning: text,
-		Action:    "analyze",
-	}
-
-	text = strings.ToLower(text)
-
-	switch {
-	case strings.Contains(text, "click") || strings.Contains(text, "press"):
-		resp.Action = "click"
-	case strings.Contains(text, "type") || strings.Contains(text, "enter"):
-		resp.Action = "type"
-	case strings.Contains(text, "navigate") || strings.Contains(text, "go to"):
-		resp.Action = "navigate"
-	case strings.Contains(text, "complete") || strings.Contains(text, "finished"):
-		resp.Action = "complete"
-		resp.Completed = true
+	message := chatResp.Choices[0].Message
+	if len(message.ToolCalls) == 0 {
+		return toolCall{Content: message.Content}, nil
 	}
 
-	return resp
+	function := message.ToolCalls[0].Function
+	return toolCall{
+		Name:      function.Name,
+		Arguments: json.RawMessage(function.Arguments),
+	}, nil
 }
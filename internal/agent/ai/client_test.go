@@ -0,0 +1,269 @@
+package ai_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/ai"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+)
+
+// textOnlyProvider OpenAI-совместимый сервер модели без vision
+type textOnlyProvider struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (tp *textOnlyProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	tp.mu.Lock()
+	tp.requests = append(tp.requests, string(body))
+	tp.mu.Unlock()
+
+	if strings.Contains(string(body), `"image_url"`) {
+		http.Error(w, `{"error":{"message":"Invalid content type. image_url is only supported by certain models."}}`, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"choices": []any{map[string]any{
+			"message": map[string]any{
+				"role": "assistant",
+				"tool_calls": []any{map[string]any{
+					"function": map[string]any{
+						"name":      "scroll",
+						"arguments": `{"reasoning": "look further"}`,
+					},
+				}},
+			},
+		}},
+	})
+}
+
+func (tp *textOnlyProvider) images() []bool {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	images := make([]bool, 0, len(tp.requests))
+	for _, req := range tp.requests {
+		images = append(images, strings.Contains(req, `"image_url"`))
+	}
+	return images
+}
+
+func TestProviderFallsBackToTextOnly(t *testing.T) {
+	t.Parallel()
+
+	provider := &textOnlyProvider{}
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+
+	client := ai.NewOpenAICompatibleClient(server.URL, "", "text-model")
+
+	messages := ai.BuildDecisionPrompt("Find docs", "Current URL: about:blank", "No recent actions")
+	messages[len(messages)-1].Images = []entity.AiImage{{MediaType: "image/jpeg", Data: []byte{0xff, 0xd8, 0xff}}}
+
+	for range 2 {
+		resp, err := client.Call(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("call: %v", err)
+		}
+		if resp.Action != "scroll" {
+			t.Errorf("action = %q, want scroll", resp.Action)
+		}
+	}
+
+	// Первый запрос с изображением отклонён и повторён без него,
+	// дальше модель получает только текст
+	got := provider.images()
+	want := []bool{true, false, false}
+	if len(got) != len(want) {
+		t.Fatalf("requests with images = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("requests with images = %v, want %v", got, want)
+		}
+	}
+}
+
+// scriptedProvider OpenAI-совместимый сервер, отвечающий заданными вызовами инструментов
+type scriptedProvider struct {
+	// tool вызываемый инструмент, по умолчанию complete
+	tool      string
+	mu        sync.Mutex
+	responses []string
+	requests  []map[string]any
+}
+
+func (sp *scriptedProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request map[string]any
+	json.NewDecoder(r.Body).Decode(&request)
+
+	sp.mu.Lock()
+	sp.requests = append(sp.requests, request)
+	arguments := sp.responses[0]
+	sp.responses = sp.responses[1:]
+	sp.mu.Unlock()
+
+	tool := sp.tool
+	if tool == "" {
+		tool = "complete"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"choices": []any{map[string]any{
+			"message": map[string]any{
+				"role": "assistant",
+				"tool_calls": []any{map[string]any{
+					"function": map[string]any{"name": tool, "arguments": arguments},
+				}},
+			},
+		}},
+	})
+}
+
+func TestProviderAnswerSchema(t *testing.T) {
+	t.Parallel()
+
+	provider := &scriptedProvider{responses: []string{
+		`{"reasoning": "done", "data": {"price": "ten"}}`,
+		`{"reasoning": "done", "data": {"price": 10}}`,
+	}}
+	server := httptest.NewServer(provider)
+	t.Cleanup(server.Close)
+
+	schema, err := jsonschema.Parse([]byte(`{
+		"type": "object",
+		"properties": {"price": {"type": "number"}},
+		"required": ["price"]
+	}`))
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+
+	client := ai.NewOpenAICompatibleClient(server.URL, "", "model")
+	ctx := ai.WithAnswerSchema(context.Background(), schema)
+
+	resp, err := client.Call(ctx, ai.BuildDecisionPrompt("Find the price", "", ""))
+	if err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if string(resp.Data) != `{"price": 10}` {
+		t.Errorf("data = %s, want the re-asked answer", resp.Data)
+	}
+
+	// Схема объявлена модели в параметрах complete, ответ не по схеме переспрошен
+	if len(provider.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(provider.requests))
+	}
+	var complete map[string]any
+	for _, tool := range provider.requests[0]["tools"].([]any) {
+		function := tool.(map[string]any)["function"].(map[string]any)
+		if function["name"] == "complete" {
+			complete = function["parameters"].(map[string]any)
+		}
+	}
+	// data не обязателен: complete может завершить задачу данными последнего extract
+	data := complete["properties"].(map[string]any)["data"].(map[string]any)
+	if data["type"] != "object" || slices.Contains(complete["required"].([]any), any("data")) {
+		t.Errorf("complete parameters do not carry the answer schema: %v", complete)
+	}
+}
+
+func TestProviderActionArguments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tool      string
+		responses []string
+		// requests больше одного - первый ответ переспрошен
+		requests int
+		check    func(t *testing.T, resp *entity.AiResponse)
+	}{
+		{
+			tool: "select",
+			responses: []string{
+				`{"reasoning": "pick size", "element_id": 3}`,
+				`{"reasoning": "pick size", "element_id": 3, "option": "Medium"}`,
+			},
+			requests: 2,
+			check: func(t *testing.T, resp *entity.AiResponse) {
+				if resp.ElementID != 3 || resp.Option != "Medium" {
+					t.Errorf("select = [%d] %q, want [3] Medium", resp.ElementID, resp.Option)
+				}
+			},
+		},
+		{
+			tool:      "press_key",
+			responses: []string{`{"reasoning": "submit search", "keys": "Enter"}`},
+			requests:  1,
+			check: func(t *testing.T, resp *entity.AiResponse) {
+				if resp.Keys != "Enter" || resp.ElementID != 0 || resp.Target != "" {
+					t.Errorf("press_key = %+v, want Enter without element", resp)
+				}
+			},
+		},
+		{
+			tool:      "check",
+			responses: []string{`{"reasoning": "no gift wrap", "target": "Gift wrap", "checked": false}`},
+			requests:  1,
+			check: func(t *testing.T, resp *entity.AiResponse) {
+				if resp.IsChecked() {
+					t.Error("check with checked=false checks the box")
+				}
+			},
+		},
+		{
+			tool: "upload",
+			responses: []string{
+				`{"reasoning": "attach resume", "file": "resume.pdf"}`,
+				`{"reasoning": "attach resume", "element_id": 7, "file": "resume.pdf"}`,
+			},
+			requests: 2,
+			check: func(t *testing.T, resp *entity.AiResponse) {
+				if resp.ElementID != 7 || resp.File != "resume.pdf" {
+					t.Errorf("upload = [%d] %q, want [7] resume.pdf", resp.ElementID, resp.File)
+				}
+			},
+		},
+		{
+			tool:      "go_back",
+			responses: []string{`{"reasoning": "wrong page"}`},
+			requests:  1,
+			check:     func(t *testing.T, resp *entity.AiResponse) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			provider := &scriptedProvider{tool: tt.tool, responses: tt.responses}
+			server := httptest.NewServer(provider)
+			t.Cleanup(server.Close)
+
+			client := ai.NewOpenAICompatibleClient(server.URL, "", "model")
+			resp, err := client.Call(context.Background(), ai.BuildDecisionPrompt("Fill the form", "", ""))
+			if err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if resp.Action != tt.tool {
+				t.Errorf("action = %q, want %q", resp.Action, tt.tool)
+			}
+			if len(provider.requests) != tt.requests {
+				t.Errorf("requests = %d, want %d", len(provider.requests), tt.requests)
+			}
+			tt.check(t, resp)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrMockScriptExhausted = errors.New("mock script exhausted")
+
+// MockScript сценарий ответов для MockClient.
+// Rules проверяются первыми и не расходуются, Steps отдаются по порядку.
+type MockScript struct {
+	Rules []MockStep `json:"rules"`
+	Steps []MockStep `json:"steps"`
+}
+
+type MockStep struct {
+	// Match условия для правил, для шагов не обязательны
+	Match *MockMatch `json:"match,omitempty"`
+	// Response ответ модели, не используется если задан Error
+	Response *entity.AiResponse `json:"response,omitempty"`
+	// Error ошибка вызова вместо ответа
+	Error string `json:"error,omitempty"`
+	// Latency задержка перед ответом, например "1.5s"
+	Latency string `json:"latency,omitempty"`
+
+	match   *mockMatcher
+	latency time.Duration
+}
+
+// MockMatch регулярные выражения по URL страницы и тексту задачи из промпта
+type MockMatch struct {
+	URL  string `json:"url,omitempty"`
+	Task string `json:"task,omitempty"`
+}
+
+type mockMatcher struct {
+	url  *regexp.Regexp
+	task *regexp.Regexp
+}
+
+// MockClient детерминированный клиент без сети, воспроизводит MockScript
+type MockClient struct {
+	mu     sync.Mutex
+	script MockScript
+	next   int
+	calls  [][]entity.AiMessage
+}
+
+// LoadMockScript читает сценарий из YAML или JSON файла
+func LoadMockScript(path string) (MockScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MockScript{}, fmt.Errorf("failed to read mock script: %v", err)
+	}
+	return ParseMockScript(data)
+}
+
+func ParseMockScript(data []byte) (MockScript, error) {
+	// YAML приводим к JSON, чтобы использовать json теги entity.AiResponse
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return MockScript{}, fmt.Errorf("failed to parse mock script: %v", err)
+	}
+
+	jsonData, err := json.Marshal(raw)
+	if err != nil {
+		return MockScript{}, fmt.Errorf("failed to parse mock script: %v", err)
+	}
+
+	var script MockScript
+	if err := json.Unmarshal(jsonData, &script); err != nil {
+		return MockScript{}, fmt.Errorf("failed to parse mock script: %v", err)
+	}
+
+	return script, nil
+}
+
+func NewMockClient(script MockScript) (*MockClient, error) {
+	for _, steps := range [][]MockStep{script.Rules, script.Steps} {
+		for i := range steps {
+			if err := steps[i].compile(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, rule := range script.Rules {
+		if rule.match == nil {
+			return nil, fmt.Errorf("mock rule %d has no match", i)
+		}
+	}
+
+	return &MockClient{script: script}, nil
+}
+
+func (m *MockClient) Call(ctx context.Context, messages []entity.AiMessage) (*entity.AiResponse, error) {
+	step, err := m.pick(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	if step.latency > 0 {
+		timer := time.NewTimer(step.latency)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if step.Error != "" {
+		return nil, errors.New(step.Error)
+	}
+	if step.Response == nil {
+		return nil, fmt.Errorf("mock step has neither response nor error")
+	}
+
+	resp := *step.Response
+	return &resp, nil
+}
+
+func (m *MockClient) Model() string {
+	return string(ProviderMock)
+}
+
+// Calls возвращает сообщения всех вызовов, для проверок в тестах
+func (m *MockClient) Calls() [][]entity.AiMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([][]entity.AiMessage(nil), m.calls...)
+}
+
+func (m *MockClient) pick(messages []entity.AiMessage) (MockStep, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls = append(m.calls, messages)
+
+	url, task := promptFields(messages)
+
+	for _, rule := range m.script.Rules {
+		if rule.match.matches(url, task) {
+			return rule, nil
+		}
+	}
+
+	for m.next < len(m.script.Steps) {
+		step := m.script.Steps[m.next]
+		m.next++
+		if step.match == nil || step.match.matches(url, task) {
+			return step, nil
+		}
+	}
+
+	return MockStep{}, ErrMockScriptExhausted
+}
+
+func (s *MockStep) compile() error {
+	if s.Latency != "" {
+		latency, err := time.ParseDuration(s.Latency)
+		if err != nil {
+			return fmt.Errorf("invalid mock latency %q: %v", s.Latency, err)
+		}
+		s.latency = latency
+	}
+
+	if s.Match == nil {
+		return nil
+	}
+
+	s.match = &mockMatcher{}
+	var err error
+	if s.Match.URL != "" {
+		if s.match.url, err = regexp.Compile(s.Match.URL); err != nil {
+			return fmt.Errorf("invalid mock url pattern: %v", err)
+		}
+	}
+	if s.Match.Task != "" {
+		if s.match.task, err = regexp.Compile(s.Match.Task); err != nil {
+			return fmt.Errorf("invalid mock task pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+func (mm *mockMatcher) matches(url, task string) bool {
+	if mm.url != nil && !mm.url.MatchString(url) {
+		return false
+	}
+	if mm.task != nil && !mm.task.MatchString(task) {
+		return false
+	}
+	return true
+}
+
+// promptFields достает URL страницы и задачу из промпта BuildDecisionPrompt
+func promptFields(messages []entity.AiMessage) (url, task string) {
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		for _, line := range strings.Split(msg.Content, "\n") {
+			switch {
+			case strings.HasPrefix(line, "TASK: "):
+				task = strings.TrimPrefix(line, "TASK: ")
+			case strings.HasPrefix(line, "Current URL: "):
+				url = strings.TrimPrefix(line, "Current URL: ")
+			}
+		}
+	}
+	return url, task
+}
@@ -0,0 +1,20 @@
+package ai
+
+import "context"
+
+type modelKey struct{}
+
+// WithModel переопределяет модель провайдера для вызовов с этим контекстом
+func WithModel(ctx context.Context, model string) context.Context {
+	if model == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+func modelFromContext(ctx context.Context, fallback string) string {
+	if model, ok := ctx.Value(modelKey{}).(string); ok && model != "" {
+		return model
+	}
+	return fallback
+}
@@ -0,0 +1,292 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+)
+
+// maxReasks сколько раз переспрашиваем модель после некорректного ответа
+const maxReasks = 2
+
+// Tool действие агента, объявленное модели как функция
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  jsonschema.Schema
+	// Check дополнительная проверка, которую не выразить схемой
+	Check func(resp *entity.AiResponse) error
+}
+
+// Общие для всех действий аргументы
+const (
+	reasoningParam    = `"reasoning": {"type": "string", "minLength": 1, "description": "Your step-by-step reasoning"}`
+	needApprovalParam = `"need_approval": {"type": "boolean", "description": "true for destructive actions like purchases, deletions, etc."}`
+)
+
+// Параметры действий над элементом страницы
+const elementParams = `"element_id": {"type": "integer", "minimum": 1, "description": "Number of the element from page state, e.g. 12 for [12]"},
+			"target": {"type": "string", "minLength": 1, "description": "Element description when it has no number: exact visible text, label or css selector"}`
+
+func requireElement(resp *entity.AiResponse) error {
+	if resp.ElementID == 0 && resp.Target == "" {
+		return fmt.Errorf("either element_id or target is required")
+	}
+	return nil
+}
+
+func toolParams(props string, required ...string) jsonschema.Schema {
+	required = append([]string{"reasoning"}, required...)
+	requiredJSON, _ := json.Marshal(required)
+
+	if props != "" {
+		props += ",\n"
+	}
+	raw := fmt.Sprintf(`{
+		"type": "object",
+		"properties": {
+			%s%s,
+			%s
+		},
+		"required": %s,
+		"additionalProperties": false
+	}`, props, reasoningParam, needApprovalParam, requiredJSON)
+
+	schema, err := jsonschema.Parse([]byte(raw))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+var Tools = []Tool{
+	{
+		Name:        "click",
+		Description: "Click on an element (button, link, etc.)",
+		Parameters:  toolParams(elementParams),
+		Check:       requireElement,
+	},
+	{
+		Name:        "type",
+		Description: "Type text into an input field",
+		Parameters: toolParams(
+			elementParams+`,
+			"text": {"type": "string", "description": "Text to type"}`,
+			"text",
+		),
+		Check: requireElement,
+	},
+	{
+		Name:        "select",
+		Description: "Choose an option in a dropdown (select element)",
+		Parameters: toolParams(
+			elementParams+`,
+			"option": {"type": "string", "minLength": 1, "description": "Value or visible label of the option"}`,
+			"option",
+		),
+		Check: requireElement,
+	},
+	{
+		Name:        "hover",
+		Description: "Move the mouse over an element, e.g. to open a menu or show a tooltip",
+		Parameters:  toolParams(elementParams),
+		Check:       requireElement,
+	},
+	{
+		Name:        "press_key",
+		Description: "Press a key or key combination, e.g. Enter to submit a search. Without an element the key goes to the focused one",
+		Parameters: toolParams(
+			elementParams+`,
+			"keys": {"type": "string", "minLength": 1, "description": "Key or combination: Enter, Escape, ArrowDown, Control+A"}`,
+			"keys",
+		),
+	},
+	{
+		Name:        "check",
+		Description: "Check or uncheck a checkbox or radio button",
+		Parameters: toolParams(
+			elementParams + `,
+			"checked": {"type": "boolean", "description": "false to uncheck, default true"}`,
+		),
+		Check: requireElement,
+	},
+	{
+		Name:        "upload",
+		Description: "Attach a file to a file input",
+		Parameters: toolParams(
+			elementParams+`,
+			"file": {"type": "string", "minLength": 1, "description": "File name given in the task, relative to the uploads directory"}`,
+			"file",
+		),
+		Check: requireElement,
+	},
+	{
+		Name:        "navigate",
+		Description: "Go to a new URL",
+		Parameters: toolParams(
+			`"url": {"type": "string", "minLength": 1, "description": "URL to navigate to"}`,
+			"url",
+		),
+	},
+	{
+		Name:        "go_back",
+		Description: "Go back to the previous page in history",
+		Parameters:  toolParams(""),
+	},
+	{
+		Name:        "scroll",
+		Description: "Scroll the page to see more content",
+		Parameters:  toolParams(""),
+	},
+	{
+		Name:        "wait",
+		Description: "Wait for the page to load or update",
+		Parameters:  toolParams(""),
+	},
+	{
+		Name:        "wait_user",
+		Description: "Wait for user interaction with browser, e.g. to log in",
+		Parameters:  toolParams(""),
+	},
+	{
+		Name:        "extract",
+		Description: "Save structured data found on the page as the task result and continue",
+		Parameters:  toolParams(dataParam, "data"),
+	},
+	{
+		Name:        "complete",
+		Description: "Task is finished",
+		Parameters: toolParams(
+			`"text": {"type": "string", "description": "The answer to the task"},
+			` + dataParam,
+		),
+	},
+}
+
+// dataParam структурированный ответ, схему задаёт задача (см. WithAnswerSchema)
+const dataParam = `"data": {"description": "Structured answer to the task"}`
+
+func findTool(tools []Tool, name string) (Tool, bool) {
+	for _, tool := range tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// toolCall ответ модели: вызов инструмента или текст, если провайдер его не вызвал
+type toolCall struct {
+	Name      string
+	Arguments json.RawMessage
+	Content   string
+}
+
+// String представление вызова для истории при повторном запросе
+func (tc toolCall) String() string {
+	if tc.Name == "" {
+		return tc.Content
+	}
+	return fmt.Sprintf("%s(%s)", tc.Name, tc.Arguments)
+}
+
+// response проверяет вызов по схеме инструмента и превращает в ответ агента
+func (tc toolCall) response(tools []Tool) (*entity.AiResponse, error) {
+	name, args := tc.Name, tc.Arguments
+
+	// Провайдеры без tool calling: ждем JSON объект с полем action
+	if name == "" {
+		var err error
+		name, args, err = actionFromContent(tc.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tool, ok := findTool(tools, name)
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q", name)
+	}
+
+	if err := jsonschema.ValidateJSON(tool.Parameters, args); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %v", name, err)
+	}
+
+	var resp entity.AiResponse
+	if err := json.Unmarshal(args, &resp); err != nil {
+		return nil, fmt.Errorf("invalid arguments for %s: %v", name, err)
+	}
+	resp.Action = name
+	resp.Completed = name == "complete"
+
+	if tool.Check != nil {
+		if err := tool.Check(&resp); err != nil {
+			return nil, fmt.Errorf("invalid arguments for %s: %v", name, err)
+		}
+	}
+
+	return &resp, nil
+}
+
+func actionFromContent(content string) (string, json.RawMessage, error) {
+	jsonStart := strings.Index(content, "{")
+	jsonEnd := strings.LastIndex(content, "}") + 1
+	if jsonStart < 0 || jsonEnd <= jsonStart {
+		return "", nil, fmt.Errorf("no tool call in response")
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(content[jsonStart:jsonEnd]), &obj); err != nil {
+		return "", nil, fmt.Errorf("no tool call in response: %v", err)
+	}
+
+	name, _ := obj["action"].(string)
+	if name == "" {
+		return "", nil, fmt.Errorf("no tool call in response")
+	}
+	delete(obj, "action")
+	delete(obj, "completed")
+
+	args, err := json.Marshal(obj)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, args, nil
+}
+
+const reaskPrompt = `Your previous response was invalid: %v
+
+Respond again by calling exactly one of the provided tools with valid arguments.`
+
+// decide запрашивает модель, пока она не вернет корректный вызов инструмента,
+// но не более maxReasks повторов
+func decide(
+	messages []entity.AiMessage,
+	tools []Tool,
+	call func(messages []entity.AiMessage) (toolCall, error),
+) (*entity.AiResponse, error) {
+
+	for attempt := 0; ; attempt++ {
+		tc, err := call(messages)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := tc.response(tools)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= maxReasks {
+			return nil, fmt.Errorf("invalid model response after %d attempts: %v", attempt+1, err)
+		}
+
+		// Не трогаем исходный слайс вызывающего
+		messages = append(messages[:len(messages):len(messages)],
+			entity.AiMessage{Role: "assistant", Content: tc.String()},
+			entity.AiMessage{Role: "user", Content: fmt.Sprintf(reaskPrompt, err)},
+		)
+	}
+}
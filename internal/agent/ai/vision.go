@@ -0,0 +1,132 @@
+package ai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/gocherry/pkg/logs"
+)
+
+// visionFallback запоминает модели, отклонившие изображения,
+// и дальше отправляет им только текст
+type visionFallback struct {
+	textOnly sync.Map // model -> struct{}
+	log      *slog.Logger
+}
+
+func newVisionFallback() visionFallback {
+	return visionFallback{
+		log: logs.SetupLogger().With(logs.AppComponent("ai.vision")),
+	}
+}
+
+// prepare убирает изображения для моделей без vision
+func (v *visionFallback) prepare(model string, messages []entity.AiMessage) []entity.AiMessage {
+	if _, ok := v.textOnly.Load(model); ok {
+		return withoutImages(messages)
+	}
+	return messages
+}
+
+// rejected сообщает, что запрос упал из-за изображений и его стоит повторить без них
+func (v *visionFallback) rejected(model string, status int, body []byte, messages []entity.AiMessage) bool {
+	if !hasImages(messages) || !visionUnsupported(status, body) {
+		return false
+	}
+
+	if _, loaded := v.textOnly.LoadOrStore(model, struct{}{}); !loaded {
+		v.log.Warn("model does not accept images, falling back to text-only page state",
+			slog.String("model", model))
+	}
+	return true
+}
+
+// visionRejections ошибки провайдеров на изображение для модели без vision.
+// Остальные 400 с упоминанием изображений (слишком большое, битый base64)
+// не отключают vision для модели.
+var visionRejections = []struct {
+	status  int
+	message string
+}{
+	// OpenAI
+	{http.StatusBadRequest, "image_url is only supported by certain models"},
+	// DeepSeek и другие серверы, не знающие частей content с изображением
+	{http.StatusUnprocessableEntity, "unknown variant `image_url`"},
+	// Groq
+	{http.StatusBadRequest, "content must be a string"},
+	// vLLM
+	{http.StatusBadRequest, "is not a multimodal model"},
+	// Ollama, LM Studio, Anthropic
+	{http.StatusBadRequest, "does not support image"},
+	// llama.cpp без mmproj
+	{http.StatusBadRequest, "image input is not supported"},
+	{http.StatusInternalServerError, "image input is not supported"},
+	// OpenRouter
+	{http.StatusNotFound, "no endpoints found that support image input"},
+}
+
+// visionUnsupported распознает ответы API на изображения у текстовых моделей
+func visionUnsupported(status int, body []byte) bool {
+	message := strings.ToLower(errorMessage(body))
+	for _, rejection := range visionRejections {
+		if status == rejection.status && strings.Contains(message, strings.ToLower(rejection.message)) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorMessage текст ошибки из ответа API: error.message у OpenAI и Anthropic,
+// error строкой у Ollama, иначе всё тело ответа
+func errorMessage(body []byte) string {
+	var response struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || len(response.Error) == 0 {
+		return string(body)
+	}
+
+	var message string
+	if err := json.Unmarshal(response.Error, &message); err == nil {
+		return message
+	}
+	var detail struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(response.Error, &detail); err == nil && detail.Message != "" {
+		return detail.Message
+	}
+	return string(body)
+}
+
+func hasImages(messages []entity.AiMessage) bool {
+	for _, msg := range messages {
+		if len(msg.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func withoutImages(messages []entity.AiMessage) []entity.AiMessage {
+	if !hasImages(messages) {
+		return messages
+	}
+
+	stripped := make([]entity.AiMessage, 0, len(messages))
+	for _, msg := range messages {
+		msg.Images = nil
+		stripped = append(stripped, msg)
+	}
+	return stripped
+}
+
+func dataURL(image entity.AiImage) string {
+	return fmt.Sprintf("data:%s;base64,%s", image.MediaType, base64.StdEncoding.EncodeToString(image.Data))
+}
@@ -0,0 +1,33 @@
+package ai
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestVisionUnsupported(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusBadRequest, `{"error":{"message":"Invalid content type. image_url is only supported by certain models.","type":"invalid_request_error"}}`, true},
+		{http.StatusUnprocessableEntity, "Failed to deserialize the JSON body into the target type: messages[1]: unknown variant `image_url`, expected `text`", true},
+		{http.StatusBadRequest, `{"error":"llava:7b does not support images"}`, true},
+		{http.StatusBadRequest, `{"object":"error","message":"qwen2 is not a multimodal model"}`, true},
+		{http.StatusNotFound, `{"error":{"message":"No endpoints found that support image input","code":404}}`, true},
+
+		// Ошибки конкретного изображения и запроса не отключают vision
+		{http.StatusBadRequest, `{"error":{"message":"Image exceeds 5 MB maximum","type":"invalid_request_error"}}`, false},
+		{http.StatusBadRequest, `{"error":{"message":"Invalid base64 image data"}}`, false},
+		{http.StatusBadRequest, `{"error":{"message":"This model's maximum context length is 8192 tokens, multimodal input included"}}`, false},
+		{http.StatusTooManyRequests, `{"error":{"message":"image_url is only supported by certain models"}}`, false},
+		{http.StatusNotFound, `{"error":{"message":"The model gpt-vision does not exist"}}`, false},
+	} {
+		if got := visionUnsupported(tt.status, []byte(tt.body)); got != tt.want {
+			t.Errorf("visionUnsupported(%d, %s) = %t, want %t", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package browser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// interactiveRoles роли, которым выдаётся номер для element_id
+var interactiveRoles = map[string]bool{
+	"button":           true,
+	"link":             true,
+	"textbox":          true,
+	"searchbox":        true,
+	"checkbox":         true,
+	"radio":            true,
+	"switch":           true,
+	"combobox":         true,
+	"listbox":          true,
+	"option":           true,
+	"menuitem":         true,
+	"menuitemcheckbox": true,
+	"menuitemradio":    true,
+	"tab":              true,
+	"slider":           true,
+	"spinbutton":       true,
+	"treeitem":         true,
+}
+
+// ariaNodeRe строка aria snapshot с ссылкой: `- button "Buy" [disabled] [ref=s1e12]:`
+var ariaNodeRe = regexp.MustCompile(`^(\s*- )([a-z]+)(.*?) \[ref=([a-z0-9]+)\](.*)$`)
+
+// extractAccessibilityState строит состояние страницы по дереву доступности.
+// В отличие от списка элементов сохраняет вложенность (карточка товара и её кнопки),
+// landmarks и состояния [checked], [expanded], [disabled] и не ограничен viewport.
+func (p *Pager) extractAccessibilityState() (string, error) {
+	snapshot, err := p.page.Locator("body").AriaSnapshot(playwright.LocatorAriaSnapshotOptions{
+		Ref:     playwright.Bool(true),
+		Timeout: playwright.Float(10000),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to take accessibility snapshot: %v", err)
+	}
+
+	tree, err := p.numberAriaSnapshot(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	var pageContent strings.Builder
+
+	pageContent.WriteString(fmt.Sprintf("Current URL: %s\n\n", p.CurrentURL()))
+
+	title, err := p.page.Title()
+	if err == nil {
+		pageContent.WriteString(fmt.Sprintf("Page Title: %s\n\n", title))
+	}
+
+	pageContent.WriteString("--- ACCESSIBILITY TREE ---\n")
+	pageContent.WriteString(tree)
+
+	return pageContent.String(), nil
+}
+
+// numberAriaSnapshot заменяет ссылки playwright на номера data-agent-id,
+// общие с режимом DOM, и убирает служебные строки
+func (p *Pager) numberAriaSnapshot(snapshot string) (string, error) {
+	var (
+		lines   []string
+		matches [][]string
+		refs    []string
+	)
+	for _, line := range strings.Split(snapshot, "\n") {
+		trimmed := strings.TrimSpace(line)
+		// Адреса ссылок занимают много токенов, кликать по ним можно по номеру
+		if trimmed == "" || strings.HasPrefix(trimmed, "- /url:") {
+			continue
+		}
+
+		match := ariaNodeRe.FindStringSubmatch(line)
+		if match != nil && interactiveRoles[match[2]] {
+			refs = append(refs, match[4])
+		}
+		lines = append(lines, line)
+		matches = append(matches, match)
+	}
+
+	agentIDs, err := p.agentIDsByAriaRefs(refs)
+	if err != nil {
+		return "", err
+	}
+
+	var tree strings.Builder
+	for i, line := range lines {
+		match := matches[i]
+		if match == nil {
+			tree.WriteString(line + "\n")
+			continue
+		}
+
+		prefix, role, rest, ref, tail := match[1], match[2], match[3], match[4], match[5]
+		if !interactiveRoles[role] {
+			tree.WriteString(prefix + role + rest + tail + "\n")
+			continue
+		}
+		tree.WriteString(fmt.Sprintf("%s[%d] %s%s%s\n", prefix, agentIDs[ref], role, rest, tail))
+	}
+
+	return tree.String(), nil
+}
+
+// agentIDsByAriaRefs помечает элементы номерами одним вызовом в странице.
+// Ссылки видны только движку aria-ref, поэтому элементы выбираются общим локатором,
+// который возвращает их в порядке документа. Он совпадает с порядком snapshot,
+// пока на пути нет shadow DOM и aria-owns, иначе номера выдаются по каждой ссылке.
+func (p *Pager) agentIDsByAriaRefs(refs []string) (map[string]int, error) {
+	agentIDs := make(map[string]int, len(refs))
+	if len(refs) == 0 {
+		return agentIDs, nil
+	}
+
+	script := `
+    (elements, attr) => {
+        const reordered = document.querySelector('[aria-owns]') !== null ||
+            elements.some(el => {
+                if (el.getRootNode() !== document) return true;
+                for (let parent = el.parentElement; parent; parent = parent.parentElement) {
+                    if (parent.shadowRoot) return true;
+                }
+                return false;
+            });
+        if (reordered) return null;
+
+        window.__agentNextId = window.__agentNextId || 1;
+        return elements.map(el => {
+            if (!el.hasAttribute(attr)) {
+                el.setAttribute(attr, String(window.__agentNextId++));
+            }
+            return Number(el.getAttribute(attr));
+        });
+    }
+    `
+
+	locator := p.page.Locator("aria-ref=" + refs[0])
+	for _, ref := range refs[1:] {
+		locator = locator.Or(p.page.Locator("aria-ref=" + ref))
+	}
+
+	result, err := locator.EvaluateAll(script, agentIDAttr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve elements: %v", err)
+	}
+
+	if ids, ok := result.([]interface{}); ok && len(ids) == len(refs) {
+		for i, ref := range refs {
+			agentIDs[ref] = toInt(ids[i])
+		}
+		return agentIDs, nil
+	}
+
+	for _, ref := range refs {
+		agentID, err := p.agentIDByAriaRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		agentIDs[ref] = agentID
+	}
+	return agentIDs, nil
+}
+
+// agentIDByAriaRef помечает элемент номером, если его ещё нет
+func (p *Pager) agentIDByAriaRef(ref string) (int, error) {
+	script := `
+    (el, attr) => {
+        window.__agentNextId = window.__agentNextId || 1;
+        if (!el.hasAttribute(attr)) {
+            el.setAttribute(attr, String(window.__agentNextId++));
+        }
+        return Number(el.getAttribute(attr));
+    }
+    `
+
+	result, err := p.page.Locator("aria-ref="+ref).Evaluate(script, agentIDAttr, playwright.LocatorEvaluateOptions{
+		Timeout: playwright.Float(5000),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve element %s: %v", ref, err)
+	}
+	return toInt(result), nil
+}
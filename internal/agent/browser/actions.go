@@ -0,0 +1,258 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// findElement ищет элемент по номеру из состояния страницы, а без номера - по описанию
+func (p *Pager) findElement(elementID int, description string) (playwright.ElementHandle, error) {
+	var (
+		element playwright.ElementHandle
+		err     error
+	)
+	if elementID > 0 {
+		element, err = p.findElementByAgentID(elementID)
+	} else {
+		element, err = p.findElementByMultipleStrategies(description)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("element not found: %v", err)
+	}
+	return element, nil
+}
+
+// focusedElement элемент в фокусе, без фокуса - body
+func (p *Pager) focusedElement() (playwright.ElementHandle, error) {
+	handle, err := p.page.EvaluateHandle("() => document.activeElement || document.body")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find focused element: %v", err)
+	}
+	element := handle.AsElement()
+	if element == nil {
+		return nil, fmt.Errorf("no focused element")
+	}
+	return element, nil
+}
+
+// SelectOption выбирает в select вариант с таким значением или подписью.
+// Смена варианта может отправить форму, поэтому переходы проверяются.
+func (p *Pager) SelectOption(ctx context.Context, elementID int, description, option string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.selectOption(elementID, description, option)
+		})
+	})
+}
+
+func (p *Pager) selectOption(elementID int, description, option string) error {
+	p.log.Info(fmt.Sprintf("🔽 Selecting %q in [%d] %s", option, elementID, description))
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
+	}
+
+	// Playwright ждёт появления варианта до таймаута, поэтому проверяем заранее
+	// и сообщаем модели, из чего можно выбрать
+	script := `
+    (el, option) => {
+        if (el.tagName !== 'SELECT') return null;
+        const options = Array.from(el.options);
+        return {
+            found: options.some(o => o.value === option || o.label === option),
+            labels: options.map(o => o.label)
+        };
+    }
+    `
+	result, err := element.Evaluate(script, option)
+	if err != nil {
+		return fmt.Errorf("select failed: %v", err)
+	}
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("element is not a dropdown")
+	}
+	if !getBool(data, "found") {
+		var labels []string
+		for _, label := range getStrings(data, "labels") {
+			labels = append(labels, fmt.Sprintf("%q", label))
+		}
+		return fmt.Errorf("no option %q, available: %s", option, strings.Join(labels, ", "))
+	}
+
+	if _, err := element.SelectOption(playwright.SelectOptionValues{
+		ValuesOrLabels: &[]string{option},
+	}); err != nil {
+		return fmt.Errorf("select failed: %v", err)
+	}
+
+	p.log.Info("✅ Successfully selected: " + option)
+	return nil
+}
+
+// Hover наводит курсор на элемент, например чтобы раскрыть меню.
+// Обработчики наведения могут открыть другую страницу, поэтому переходы проверяются.
+func (p *Pager) Hover(ctx context.Context, elementID int, description string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.hover(elementID, description)
+		})
+	})
+}
+
+func (p *Pager) hover(elementID int, description string) error {
+	p.log.Info(fmt.Sprintf("🖱️ Hovering: [%d] %s", elementID, description))
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
+	}
+	if err := element.Hover(); err != nil {
+		return fmt.Errorf("hover failed: %v", err)
+	}
+	return nil
+}
+
+// PressKey нажимает клавишу или сочетание (Enter, Control+A) на элементе,
+// а если элемент не задан - на элементе в фокусе
+func (p *Pager) PressKey(ctx context.Context, elementID int, description, keys string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.pressKey(elementID, description, keys)
+		})
+	})
+}
+
+func (p *Pager) pressKey(elementID int, description, keys string) error {
+	p.log.Info(fmt.Sprintf("⌨️ Pressing %s on [%d] %s", keys, elementID, description))
+
+	if elementID <= 0 && description == "" {
+		if err := p.page.Keyboard().Press(keys); err != nil {
+			return fmt.Errorf("key press failed: %v", err)
+		}
+		return nil
+	}
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
+	}
+	if err := element.Press(keys); err != nil {
+		return fmt.Errorf("key press failed: %v", err)
+	}
+	return nil
+}
+
+// SetChecked отмечает флажок или переключатель, checked=false снимает отметку.
+// Переходы проверяются, как после клика.
+func (p *Pager) SetChecked(ctx context.Context, elementID int, description string, checked bool) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.setChecked(elementID, description, checked)
+		})
+	})
+}
+
+func (p *Pager) setChecked(elementID int, description string, checked bool) error {
+	p.log.Info(fmt.Sprintf("☑️ Setting checked=%t: [%d] %s", checked, elementID, description))
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
+	}
+	if err := element.SetChecked(checked); err != nil {
+		return fmt.Errorf("check failed: %v", err)
+	}
+	return nil
+}
+
+// UploadFile прикрепляет к полю выбора файла файл из каталога загрузок (BROWSER_UPLOAD_DIR).
+// Выбор файла может отправить форму, поэтому переходы проверяются.
+func (p *Pager) UploadFile(ctx context.Context, elementID int, description, file string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.uploadFile(elementID, description, file)
+		})
+	})
+}
+
+func (p *Pager) uploadFile(elementID int, description, file string) error {
+	p.log.Info(fmt.Sprintf("📎 Uploading %s to [%d] %s", file, elementID, description))
+
+	path, err := p.uploadPath(file)
+	if err != nil {
+		return err
+	}
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
+	}
+	if err := element.SetInputFiles(path); err != nil {
+		return fmt.Errorf("upload failed: %v", err)
+	}
+	return nil
+}
+
+// uploadPath путь к файлу внутри каталога загрузок.
+// Имя файла приходит от модели, поэтому пути за пределы каталога отклоняются,
+// в том числе через символические ссылки.
+func (p *Pager) uploadPath(file string) (string, error) {
+	if p.uploadDir == "" {
+		return "", fmt.Errorf("file uploads are disabled, set BROWSER_UPLOAD_DIR")
+	}
+	if !filepath.IsLocal(file) {
+		return "", fmt.Errorf("file %q is outside the uploads directory", file)
+	}
+
+	dir, err := filepath.EvalSymlinks(p.uploadDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid upload dir: %v", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, file))
+	if err != nil {
+		return "", fmt.Errorf("file %q not found in the uploads directory", file)
+	}
+	if rel, err := filepath.Rel(dir, path); err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("file %q is outside the uploads directory", file)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("file %q not found in the uploads directory", file)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%q is not a file", file)
+	}
+	return path, nil
+}
+
+// GoBack возвращается на предыдущую страницу истории
+func (p *Pager) GoBack(ctx context.Context) error {
+	return withContext(ctx, func() error {
+		return p.guard(p.goBack)
+	})
+}
+
+func (p *Pager) goBack() error {
+	p.log.Info("Going back")
+
+	before := p.page.URL()
+	response, err := p.page.GoBack(playwright.PageGoBackOptions{
+		WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+	})
+	if err != nil {
+		return fmt.Errorf("go back failed: %v", err)
+	}
+	// Ответа нет и когда назад некуда, и при переходе внутри документа (#anchor)
+	if response == nil && p.page.URL() == before {
+		return fmt.Errorf("no previous page")
+	}
+	return nil
+}
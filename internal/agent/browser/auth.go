@@ -0,0 +1,125 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// document ответ, которым загружен текущий документ страницы
+type document struct {
+	status     int
+	redirected bool
+}
+
+// trackDocument запоминает статус основного документа и был ли перед ним редирект
+func (p *Pager) trackDocument(response playwright.Response) {
+	request := response.Request()
+	if !request.IsNavigationRequest() || response.Frame() != p.page.MainFrame() {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.document = document{
+		status:     response.Status(),
+		redirected: request.RedirectedFrom() != nil,
+	}
+}
+
+// AuthSignals ищет на странице признаки входа в аккаунт:
+// поля пароля, кнопки входа и выхода, меню аккаунта
+func (p *Pager) AuthSignals(ctx context.Context) (entity.AuthSignals, error) {
+	return withContextValue(ctx, p.authSignals)
+}
+
+func (p *Pager) authSignals() (entity.AuthSignals, error) {
+	script := `
+    () => {
+        const visible = (el) => {
+            const rect = el.getBoundingClientRect();
+            return rect.width > 0 && rect.height > 0 &&
+                   getComputedStyle(el).visibility !== 'hidden';
+        };
+        const text = (el) => (el.textContent?.trim() || el.getAttribute('value') ||
+                              el.getAttribute('aria-label') || el.getAttribute('title') || '')
+            .replace(/\s+/g, ' ').slice(0, 60);
+
+        const controls = Array.from(document.querySelectorAll(
+            'a, button, input[type=submit], input[type=button], [role=button], [role=menuitem]'
+        )).filter(visible);
+
+        // \b в JS знает только латиницу, поэтому конец слова проверяем по буквам Unicode:
+        // "Войти в аккаунт" подходит, "Входящие" - нет
+        const login = controls.map(text)
+            .filter(t => /^(sign ?in|log ?in|войти|вход)(?=$|[^\p{L}\p{N}])/iu.test(t));
+        const logout = controls.map(text)
+            .filter(t => /^(sign ?out|log ?out|выйти|выход)(?=$|[^\p{L}\p{N}])/iu.test(t));
+
+        // Ссылка выхода часто спрятана в закрытом меню аккаунта
+        document.querySelectorAll(
+            'a[href*="logout" i], a[href*="signout" i], a[href*="sign_out" i], form[action*="logout" i]'
+        ).forEach(el => logout.push(text(el) || 'logout link'));
+
+        const account = Array.from(document.querySelectorAll(
+            '[class*="avatar" i], [id*="avatar" i], img[alt*="avatar" i], ' +
+            '[class*="user-menu" i], [id*="user-menu" i], [class*="account-menu" i], [data-testid*="user-menu" i]'
+        )).filter(visible).map(el => text(el) || el.getAttribute('alt') || 'avatar');
+
+        let username = '';
+        const named = document.querySelector('[data-username], [class*="username" i]:not(input), [id*="username" i]:not(input)');
+        if (named && visible(named)) {
+            username = named.getAttribute('data-username') || text(named);
+        }
+        if (!username) {
+            const match = (document.body?.innerText || '').match(
+                /(?:signed in as|logged in as|welcome,|вы вошли как)\s+([^\s,!.]+)/i
+            );
+            if (match) username = match[1];
+        }
+
+        return {
+            passwordFields: Array.from(document.querySelectorAll('input[type=password]')).filter(visible).length,
+            login: login,
+            logout: logout,
+            account: account,
+            username: username.slice(0, 40)
+        };
+    }
+    `
+
+	result, err := p.page.Evaluate(script)
+	if err != nil {
+		return entity.AuthSignals{}, fmt.Errorf("failed to detect auth signals: %v", err)
+	}
+
+	data, _ := result.(map[string]interface{})
+
+	p.mu.Lock()
+	doc := p.document
+	p.mu.Unlock()
+
+	return entity.AuthSignals{
+		Status:         doc.status,
+		Redirected:     doc.redirected,
+		PasswordFields: getInt(data, "passwordFields"),
+		Login:          getStrings(data, "login"),
+		Logout:         getStrings(data, "logout"),
+		Account:        getStrings(data, "account"),
+		Username:       getString(data, "username"),
+	}, nil
+}
+
+func getStrings(data map[string]interface{}, key string) []string {
+	items, _ := data[key].([]interface{})
+
+	var result []string
+	for _, item := range items {
+		if str, ok := item.(string); ok && str != "" {
+			result = append(result, str)
+		}
+	}
+	return result
+}
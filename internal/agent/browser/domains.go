@@ -0,0 +1,129 @@
+package browser
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// unsafeScheme схемы, которые нельзя открывать по запросу модели
+var unsafeScheme = regexp.MustCompile(`(?i)^(javascript|data|file|about|blob|chrome|vbscript|view-source):`)
+
+// normalizeURL добавляет https:// к адресам без схемы и отклоняет не http(s) адреса
+func normalizeURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	lower := strings.ToLower(rawURL)
+
+	switch {
+	case strings.HasPrefix(lower, "http://"), strings.HasPrefix(lower, "https://"):
+		return rawURL, nil
+	case strings.Contains(lower, "://"), unsafeScheme.MatchString(lower):
+		return "", &entity.DomainError{URL: rawURL, Rule: "only http and https urls are allowed"}
+	default:
+		return "https://" + rawURL, nil
+	}
+}
+
+// checkURL проверяет адрес по общим спискам и спискам задачи
+func (p *Pager) checkURL(rawURL string) error {
+	for _, rules := range p.domains {
+		if err := rules.Check(rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// routeDomains перехватывает запросы контекста, включая клики по ссылкам и popup.
+// Переходы проверяются по обоим спискам, прочие запросы - только по запрещённым
+// доменам, чтобы не ломать загрузку ресурсов с CDN. Нарушением действия считается
+// только переход страницы или popup: заблокированный iframe (реклама, аналитика,
+// платёжный виджет) отменяется молча, действие на странице при этом удалось.
+func (p *Pager) routeDomains() error {
+	return p.context.Route("**/*", func(route playwright.Route) {
+		request := route.Request()
+		navigation := request.IsNavigationRequest()
+
+		var err error
+		if navigation {
+			err = p.checkURL(request.URL())
+		} else {
+			for _, rules := range p.domains {
+				if err = rules.CheckBlocked(request.URL()); err != nil {
+					break
+				}
+			}
+		}
+
+		if err == nil {
+			route.Continue()
+			return
+		}
+
+		if navigation && !topLevel(request) {
+			p.log.Debug("frame blocked: " + err.Error())
+			route.Abort("blockedbyclient")
+			return
+		}
+
+		p.log.Warn("request blocked: " + err.Error())
+		if navigation {
+			p.recordViolation(err)
+		}
+		route.Abort("blockedbyclient")
+	})
+}
+
+// topLevel запрос документа страницы или popup, а не iframe
+func topLevel(request playwright.Request) bool {
+	frame := request.Frame()
+	return frame == nil || frame.ParentFrame() == nil
+}
+
+func (p *Pager) recordViolation(err error) {
+	var domainErr *entity.DomainError
+	if !errors.As(err, &domainErr) {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.violation = domainErr
+}
+
+func (p *Pager) takeViolation() *entity.DomainError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	violation := p.violation
+	p.violation = nil
+	return violation
+}
+
+// guard выполняет действие и сообщает о заблокированном во время него переходе.
+// Перехват не видит редиректы, поэтому итоговый адрес страницы проверяется отдельно.
+func (p *Pager) guard(fn func() error) error {
+	if len(p.domains) == 0 {
+		return fn()
+	}
+
+	p.takeViolation()
+	err := fn()
+
+	if violation := p.takeViolation(); violation != nil {
+		return violation
+	}
+
+	if violation := p.checkURL(p.page.URL()); violation != nil {
+		p.log.Warn("left blocked page: " + violation.Error())
+		if _, err := p.page.Goto("about:blank"); err != nil {
+			p.log.Warn("failed to leave blocked page: " + err.Error())
+		}
+		return violation
+	}
+
+	return err
+}
@@ -1,20 +1,95 @@
 package browser
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
 )
 
+// agentIDAttr атрибут, которым помечаются интерактивные элементы.
+// Номер стабилен в пределах документа и передается модели как element_id.
+const agentIDAttr = "data-agent-id"
+
 type ElementInfo struct {
+	AgentID int
 	TagName string
 	Text    string
 	ID      string
 	Classes string
 	Visible bool
 	Type    string
+	// Checked состояние флажка или переключателя
+	Checked bool
+	// Options подписи вариантов select, Selected - выбранный
+	Options  []string
+	Selected string
+}
+
+// String форматирует элемент для промпта: [12] button "Add to cart",
+// для select и флажков добавляет их состояние
+func (el ElementInfo) String() string {
+	kind := el.TagName
+	switch el.TagName {
+	case "a":
+		kind = "link"
+	case "input":
+		if el.Type != "" {
+			kind = fmt.Sprintf("input[%s]", el.Type)
+		}
+	}
+	line := fmt.Sprintf("[%d] %s %q", el.AgentID, kind, el.Text)
+
+	switch {
+	case el.TagName == "select":
+		options := make([]string, 0, len(el.Options))
+		for _, option := range el.Options {
+			options = append(options, fmt.Sprintf("%q", option))
+		}
+		line += fmt.Sprintf(" selected %q, options: %s", el.Selected, strings.Join(options, ", "))
+	case el.Type == "checkbox" || el.Type == "radio":
+		if el.Checked {
+			line += " (checked)"
+		} else {
+			line += " (unchecked)"
+		}
+	}
+	return line
+}
+
+func (el ElementInfo) isLink() bool {
+	return el.TagName == "a"
+}
+
+func (el ElementInfo) isFormElement() bool {
+	switch el.TagName {
+	case "textarea", "select":
+		return true
+	case "input":
+		switch el.Type {
+		case "button", "submit", "reset":
+			return false
+		}
+		return true
+	}
+	return false
 }
 
-func (p *Pager) ExtractPageState() (string, error) {
+func (p *Pager) ExtractPageState(ctx context.Context, mode entity.SnapshotMode) (string, error) {
+	switch mode {
+	case entity.SnapshotModeAccessibility:
+		return withContextValue(ctx, p.extractAccessibilityState)
+	case entity.SnapshotModeDOM, "":
+		return withContextValue(ctx, p.extractPageState)
+	default:
+		return "", fmt.Errorf("unknown snapshot mode: %s", mode)
+	}
+}
+
+func (p *Pager) extractPageState() (string, error) {
 	var pageContent strings.Builder
 
 	// Извлекаем основные элементы
@@ -36,27 +111,27 @@ func (p *Pager) ExtractPageState() (string, error) {
 	// Группируем элементы по типам
 	pageContent.WriteString("=== INTERACTIVE ELEMENTS ===\n")
 
-	// Кнопки
+	// Кнопки и прочие кликабельные элементы
 	pageContent.WriteString("\n--- BUTTONS ---\n")
 	for _, el := range elements {
-		if el.TagName == "button" || el.Type == "button" {
-			pageContent.WriteString(fmt.Sprintf("- %s\n", el.Text))
+		if !el.isLink() && !el.isFormElement() {
+			pageContent.WriteString(el.String() + "\n")
 		}
 	}
 
 	// Ссылки
 	pageContent.WriteString("\n--- LINKS ---\n")
 	for _, el := range elements {
-		if el.TagName == "a" {
-			pageContent.WriteString(fmt.Sprintf("- %s\n", el.Text))
+		if el.isLink() {
+			pageContent.WriteString(el.String() + "\n")
 		}
 	}
 
 	// Формы
 	pageContent.WriteString("\n--- FORM ELEMENTS ---\n")
 	for _, el := range elements {
-		if el.TagName == "input" || el.TagName == "textarea" {
-			pageContent.WriteString(fmt.Sprintf("- %s [%s]\n", el.Text, el.Type))
+		if el.isFormElement() {
+			pageContent.WriteString(el.String() + "\n")
 		}
 	}
 
@@ -74,15 +149,30 @@ func (p *Pager) ExtractPageState() (string, error) {
 
 func (p *Pager) extractInteractiveElements() ([]ElementInfo, error) {
 	script := `
-    () => {
+    (attr) => {
         const elements = [];
+        const seen = new Set();
         const selectors = [
-            'a', 'button', 'input', 'textarea', 
+            'a', 'button', 'input', 'textarea', 'select',
             '[role="button"]', '[onclick]', '[type="submit"]'
         ];
+
+        // Текст label без вложенных полей, иначе у select в подпись попадут все варианты
+        const labelText = (label) => {
+            if (!label) return '';
+            const copy = label.cloneNode(true);
+            copy.querySelectorAll('input, select, textarea, button').forEach(c => c.remove());
+            return copy.textContent.trim();
+        };
+
+        // Номера не переиспользуются в пределах документа
+        window.__agentNextId = window.__agentNextId || 1;
         
         selectors.forEach(selector => {
             document.querySelectorAll(selector).forEach(el => {
+                if (seen.has(el)) return;
+                seen.add(el);
+
                 const rect = el.getBoundingClientRect();
                 const isVisible = rect.width > 0 && rect.height > 0 && 
                                 rect.top >= 0 && rect.left >= 0 &&
@@ -90,20 +180,39 @@ func (p *Pager) extractInteractiveElements() ([]ElementInfo, error) {
                                 rect.right <= window.innerWidth;
                 
                 if (isVisible) {
-                    const text = el.textContent?.trim() || 
+                    const tag = el.tagName.toLowerCase();
+                    const label = el.getAttribute('aria-label') ||
+                                labelText(el.labels?.[0]) ||
+                                el.getAttribute('name') ||
+                                '';
+                    // У select текст - все варианты, у флажков и файлов текста нет
+                    const labeled = tag === 'select' ||
+                                ['checkbox', 'radio', 'file'].includes(el.type);
+                    const text = labeled ? label :
+                                el.textContent?.trim() || 
                                 el.getAttribute('placeholder') ||
                                 el.getAttribute('value') ||
-                                el.getAttribute('aria-label') ||
-                                '';
+                                label;
                     
                     if (text && text.length < 100) { // Ограничиваем длину текста
+                        if (!el.hasAttribute(attr)) {
+                            el.setAttribute(attr, String(window.__agentNextId++));
+                        }
                         elements.push({
-                            tagName: el.tagName.toLowerCase(),
+                            agentId: Number(el.getAttribute(attr)),
+                            tagName: tag,
                             text: text,
                             id: el.id || '',
                             classes: el.className || '',
                             visible: isVisible,
-                            type: el.type || ''
+                            type: el.type || '',
+                            checked: el.checked === true,
+                            options: tag === 'select'
+                                ? Array.from(el.options).slice(0, 20).map(o => o.label)
+                                : [],
+                            selected: tag === 'select' && el.selectedIndex >= 0
+                                ? el.options[el.selectedIndex].label
+                                : ''
                         });
                     }
                 }
@@ -114,7 +223,7 @@ func (p *Pager) extractInteractiveElements() ([]ElementInfo, error) {
     }
     `
 
-	result, err := p.page.Evaluate(script)
+	result, err := p.page.Evaluate(script, agentIDAttr)
 	if err != nil {
 		return nil, err
 	}
@@ -125,12 +234,16 @@ func (p *Pager) extractInteractiveElements() ([]ElementInfo, error) {
 		for _, item := range items {
 			if data, ok := item.(map[string]interface{}); ok {
 				element := ElementInfo{
-					TagName: getString(data, "tagName"),
-					Text:    getString(data, "text"),
-					ID:      getString(data, "id"),
-					Classes: getString(data, "classes"),
-					Visible: getBool(data, "visible"),
-					Type:    getString(data, "type"),
+					AgentID:  getInt(data, "agentId"),
+					TagName:  getString(data, "tagName"),
+					Text:     getString(data, "text"),
+					ID:       getString(data, "id"),
+					Classes:  getString(data, "classes"),
+					Visible:  getBool(data, "visible"),
+					Type:     getString(data, "type"),
+					Checked:  getBool(data, "checked"),
+					Options:  getStrings(data, "options"),
+					Selected: getString(data, "selected"),
 				}
 				if element.Text != "" {
 					elements = append(elements, element)
@@ -182,6 +295,22 @@ func getString(data map[string]interface{}, key string) string {
 	return ""
 }
 
+func getInt(data map[string]interface{}, key string) int {
+	return toInt(data[key])
+}
+
+func toInt(value interface{}) int {
+	switch val := value.(type) {
+	case int:
+		return val
+	case int64:
+		return int(val)
+	case float64:
+		return int(val)
+	}
+	return 0
+}
+
 func getBool(data map[string]interface{}, key string) bool {
 	if val, ok := data[key]; ok {
 		if b, ok := val.(bool); ok {
@@ -190,3 +319,77 @@ func getBool(data map[string]interface{}, key string) bool {
 	}
 	return false
 }
+
+// DescribeElement возвращает тег, тип, текст и поля формы элемента.
+// Элемент ищется так же, как в ClickElement и других действиях,
+// без номера и описания описывается элемент в фокусе, на который уйдёт PressKey.
+func (p *Pager) DescribeElement(ctx context.Context, elementID int, description string) (entity.Element, error) {
+	return withContextValue(ctx, func() (entity.Element, error) {
+		return p.describeElement(elementID, description)
+	})
+}
+
+func (p *Pager) describeElement(elementID int, description string) (entity.Element, error) {
+	var (
+		element playwright.ElementHandle
+		err     error
+	)
+	if elementID <= 0 && description == "" {
+		element, err = p.focusedElement()
+	} else {
+		element, err = p.findElement(elementID, description)
+	}
+	if err != nil {
+		return entity.Element{}, err
+	}
+
+	script := `
+    (el) => {
+        const field = (f) => ({
+            type: f.type || '',
+            name: f.name || f.id || '',
+            autocomplete: f.getAttribute('autocomplete') || ''
+        });
+
+        const tag = el.tagName.toLowerCase();
+        let selector = tag;
+        if (el.id) selector += '#' + el.id;
+        if (typeof el.className === 'string' && el.className.trim()) {
+            selector += '.' + el.className.trim().split(/\s+/).join('.');
+        }
+        if (el.getAttribute('name')) selector += '[name=' + el.getAttribute('name') + ']';
+        if (el.getAttribute('type')) selector += '[type=' + el.getAttribute('type') + ']';
+
+        const form = el.form || el.closest('form');
+        const fields = form
+            ? Array.from(form.querySelectorAll('input, textarea, select')).map(field)
+            : [];
+
+        return {
+            ...field(el),
+            tag: tag,
+            text: (el.textContent?.trim() || el.getAttribute('placeholder') ||
+                   el.getAttribute('value') || el.getAttribute('aria-label') || '').slice(0, 100),
+            selector: selector,
+            form: fields
+        };
+    }
+    `
+
+	result, err := element.Evaluate(script)
+	if err != nil {
+		return entity.Element{}, fmt.Errorf("failed to describe element: %v", err)
+	}
+
+	// Результат приводим через JSON, чтобы использовать теги entity.Element
+	data, err := json.Marshal(result)
+	if err != nil {
+		return entity.Element{}, fmt.Errorf("failed to describe element: %v", err)
+	}
+
+	var described entity.Element
+	if err := json.Unmarshal(data, &described); err != nil {
+		return entity.Element{}, fmt.Errorf("failed to describe element: %v", err)
+	}
+	return described, nil
+}
@@ -0,0 +1,155 @@
+package browser
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// launchKey браузеры с разным движком или режимом запускаются отдельно
+type launchKey struct {
+	engine   entity.BrowserEngine
+	headless bool
+}
+
+// options настройки браузера агента из конфигурации
+func (conf Config) options() (entity.BrowserOptions, error) {
+	options := entity.BrowserOptions{
+		Headless:          playwright.Bool(conf.Headless),
+		Engine:            conf.Engine,
+		Device:            conf.Device,
+		UserAgent:         conf.UserAgent,
+		Locale:            conf.Locale,
+		Timezone:          conf.Timezone,
+		Proxy:             conf.Proxy,
+		Headers:           conf.Headers,
+		NavigationTimeout: conf.NavigationTimeout,
+	}
+
+	if conf.Viewport != "" {
+		width, height, ok := strings.Cut(conf.Viewport, "x")
+		w, errW := strconv.Atoi(width)
+		h, errH := strconv.Atoi(height)
+		if !ok || errW != nil || errH != nil {
+			return options, fmt.Errorf("invalid viewport %q, want WIDTHxHEIGHT", conf.Viewport)
+		}
+		options.Viewport = &entity.Viewport{Width: w, Height: h}
+	}
+
+	if conf.Geolocation != "" {
+		var coords []float64
+		for _, part := range strings.Split(conf.Geolocation, ",") {
+			value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return options, fmt.Errorf("invalid geolocation %q, want LAT,LON[,ACCURACY]", conf.Geolocation)
+			}
+			coords = append(coords, value)
+		}
+		if len(coords) < 2 || len(coords) > 3 {
+			return options, fmt.Errorf("invalid geolocation %q, want LAT,LON[,ACCURACY]", conf.Geolocation)
+		}
+		options.Geolocation = &entity.Geolocation{Latitude: coords[0], Longitude: coords[1]}
+		if len(coords) == 3 {
+			options.Geolocation.Accuracy = coords[2]
+		}
+	}
+
+	return options, options.Validate()
+}
+
+// launch возвращает запущенный браузер с нужным движком и режимом, запуская его при первом обращении
+func (ba *BrowserAgent) launch(key launchKey) (playwright.Browser, error) {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+
+	if browser, ok := ba.browsers[key]; ok {
+		return browser, nil
+	}
+
+	var browserType playwright.BrowserType
+	switch key.engine {
+	case entity.BrowserEngineFirefox:
+		browserType = ba.pw.Firefox
+	case entity.BrowserEngineWebKit:
+		browserType = ba.pw.WebKit
+	default:
+		browserType = ba.pw.Chromium
+	}
+
+	browser, err := browserType.Launch(playwright.BrowserTypeLaunchOptions{
+		Headless: playwright.Bool(key.headless),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: could not launch %s: %v", ErrUnavailable, key.engine, err)
+	}
+
+	ba.browsers[key] = browser
+	return browser, nil
+}
+
+// contextOptions переводит настройки задачи в настройки контекста playwright
+func (ba *BrowserAgent) contextOptions(options entity.BrowserOptions) (playwright.BrowserNewContextOptions, error) {
+	var contextOptions playwright.BrowserNewContextOptions
+
+	if options.Device != "" {
+		device, ok := ba.pw.Devices[options.Device]
+		if !ok {
+			return contextOptions, fmt.Errorf("unknown device: %s", options.Device)
+		}
+		contextOptions.UserAgent = playwright.String(device.UserAgent)
+		contextOptions.Viewport = device.Viewport
+		contextOptions.Screen = device.Screen
+		contextOptions.DeviceScaleFactor = playwright.Float(device.DeviceScaleFactor)
+		contextOptions.IsMobile = playwright.Bool(device.IsMobile)
+		contextOptions.HasTouch = playwright.Bool(device.HasTouch)
+	}
+
+	if options.Viewport != nil {
+		contextOptions.Viewport = &playwright.Size{Width: options.Viewport.Width, Height: options.Viewport.Height}
+	}
+	// Окно без фиксированного viewport имеет смысл только в видимом режиме
+	if contextOptions.Viewport == nil && options.Headless != nil && !*options.Headless {
+		contextOptions.NoViewport = playwright.Bool(true)
+	}
+
+	if options.UserAgent != "" {
+		contextOptions.UserAgent = playwright.String(options.UserAgent)
+	}
+	if options.Locale != "" {
+		contextOptions.Locale = playwright.String(options.Locale)
+	}
+	if options.Timezone != "" {
+		contextOptions.TimezoneId = playwright.String(options.Timezone)
+	}
+
+	if g := options.Geolocation; g != nil {
+		contextOptions.Geolocation = &playwright.Geolocation{
+			Latitude:  g.Latitude,
+			Longitude: g.Longitude,
+			Accuracy:  playwright.Float(g.Accuracy),
+		}
+		contextOptions.Permissions = []string{"geolocation"}
+	}
+
+	if options.Proxy != "" {
+		// Прокси проверен в Validate
+		u, _ := url.Parse(options.Proxy)
+		proxy := &playwright.Proxy{Server: u.Scheme + "://" + u.Host}
+		if u.User != nil {
+			password, _ := u.User.Password()
+			proxy.Username = playwright.String(u.User.Username())
+			proxy.Password = playwright.String(password)
+		}
+		contextOptions.Proxy = proxy
+	}
+
+	if len(options.Headers) > 0 {
+		contextOptions.ExtraHttpHeaders = options.Headers
+	}
+
+	return contextOptions, nil
+}
@@ -1,43 +1,157 @@
 package browser
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
 )
 
 type Pager struct {
-	page playwright.Page
-	log  *slog.Logger
+	page    playwright.Page
+	context playwright.BrowserContext
+	log     *slog.Logger
+
+	// domains общие ограничения переходов и ограничения задачи
+	domains []entity.DomainRules
+	secrets Secrets
+	// profile сессии страницы сохраняются в этот профиль при закрытии,
+	// если он не менялся с версии profileVersion, загруженной при открытии
+	profile        string
+	profileVersion string
+	profiles       Profiles
+	// uploadDir каталог файлов, доступных UploadFile
+	uploadDir string
+
+	mu sync.Mutex
+	// violation последний заблокированный переход
+	violation *entity.DomainError
+	// document ответ на загрузку текущего документа, см. AuthSignals
+	document document
 }
 
+// Close сохраняет сессии в профиль и закрывает страницу вместе с её контекстом
 func (p *Pager) Close() error {
-	return p.page.Close()
+	// Storage state доступен только до закрытия контекста
+	saveErr := p.saveProfile()
+
+	if err := p.page.Close(); err != nil {
+		return fmt.Errorf("could not close page: %v", err)
+	}
+	if err := p.context.Close(); err != nil {
+		return fmt.Errorf("could not close context: %v", err)
+	}
+	return saveErr
 }
 
-func (p *Pager) Navigate(url string) error {
-	if !strings.HasPrefix(url, "http") {
-		url = "https://" + url
+func (p *Pager) saveProfile() error {
+	if p.profile == "" {
+		return nil
 	}
 
-	p.log.Info("Navigating to " + url)
-	_, err := p.page.Goto(url, playwright.PageGotoOptions{
-		Timeout:   playwright.Float(30000),
-		WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+	state, err := p.context.StorageState()
+	if err != nil {
+		return fmt.Errorf("could not save profile %s: %v", p.profile, err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not save profile %s: %v", p.profile, err)
+	}
+	_, err = p.profiles.UpdateProfile(context.Background(), p.profile, data, p.profileVersion)
+	if errors.Is(err, entity.ErrProfileChanged) {
+		// Профиль удалён или сохранён другой задачей: не восстанавливаем и не затираем его
+		p.log.Warn("profile changed while the task was running, sessions are not saved",
+			slog.String("profile", p.profile))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not save profile %s: %v", p.profile, err)
+	}
+	return nil
+}
+
+// withContext выполняет операцию playwright, прекращая ожидание при отмене ctx.
+// Сама операция в playwright-go не отменяется и завершится по своему таймауту.
+func withContext(ctx context.Context, fn func() error) error {
+	_, err := withContextValue(ctx, func() (struct{}, error) {
+		return struct{}{}, fn()
 	})
 	return err
 }
 
-func (p *Pager) ClickElement(description string) error {
-	p.log.Info("🖱️ Attempting to click: " + description)
+func withContextValue[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
 
-	// Пытаемся найти элемент различными стратегиями
-	element, err := p.findElementByMultipleStrategies(description)
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-done:
+		return res.value, res.err
+	}
+}
+
+func (p *Pager) Navigate(ctx context.Context, url string) error {
+	return withContext(ctx, func() error {
+		return p.navigate(url)
+	})
+}
+
+func (p *Pager) navigate(rawURL string) error {
+	url, err := normalizeURL(rawURL)
 	if err != nil {
-		return fmt.Errorf("element not found: %v", err)
+		return err
+	}
+	if err := p.checkURL(url); err != nil {
+		return err
+	}
+
+	p.log.Info("Navigating to " + url)
+	return p.guard(func() error {
+		// Таймаут перехода задаётся контексту, см. Config.NavigationTimeout
+		_, err := p.page.Goto(url, playwright.PageGotoOptions{
+			WaitUntil: playwright.WaitUntilStateDomcontentloaded,
+		})
+		return err
+	})
+}
+
+// ClickElement кликает по элементу с номером elementID из состояния страницы,
+// а если номер не задан - ищет его по описанию
+func (p *Pager) ClickElement(ctx context.Context, elementID int, description string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.clickElement(elementID, description)
+		})
+	})
+}
+
+func (p *Pager) clickElement(elementID int, description string) error {
+	p.log.Info(fmt.Sprintf("🖱️ Attempting to click: [%d] %s", elementID, description))
+
+	element, err := p.findElement(elementID, description)
+	if err != nil {
+		return err
 	}
 
 	// Проверяем видимость
@@ -61,6 +175,19 @@ func (p *Pager) ClickElement(description string) error {
 	return nil
 }
 
+// findElementByAgentID ищет элемент по номеру, выданному в ExtractPageState
+func (p *Pager) findElementByAgentID(elementID int) (playwright.ElementHandle, error) {
+	selector := fmt.Sprintf("[%s='%d']", agentIDAttr, elementID)
+	element, err := p.page.QuerySelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	if element == nil {
+		return nil, fmt.Errorf("no element with id %d, page state may be outdated", elementID)
+	}
+	return element, nil
+}
+
 // findElementByMultipleStrategies ищет элемент используя multiple стратегии
 func (p *Pager) findElementByMultipleStrategies(description string) (playwright.ElementHandle, error) {
 	strategies := []struct {
@@ -378,8 +505,26 @@ func (p *Pager) findGenericClickableElement(description string) (playwright.Elem
 	return nil, fmt.Errorf("no generic clickable element found for '%s'", description)
 }
 
-func (p *Pager) TypeText(description, text string) error {
-	p.log.Info(fmt.Sprintf("⌨️ Typing in %s: %s", description, text))
+// TypeText вводит текст в поле с номером elementID, а если номер не задан -
+// в поле, найденное по описанию
+func (p *Pager) TypeText(ctx context.Context, elementID int, description, text string) error {
+	return withContext(ctx, func() error {
+		return p.guard(func() error {
+			return p.typeText(elementID, description, text)
+		})
+	})
+}
+
+func (p *Pager) typeText(elementID int, description, text string) error {
+	p.log.Info(fmt.Sprintf("⌨️ Typing in [%d] %s: %s", elementID, description, text))
+
+	if elementID > 0 {
+		element, err := p.findElementByAgentID(elementID)
+		if err != nil {
+			return fmt.Errorf("element not found: %v", err)
+		}
+		return p.fill(element, description, text, false)
+	}
 
 	element, err := p.findElementByText(description)
 	if err != nil {
@@ -388,25 +533,67 @@ func (p *Pager) TypeText(description, text string) error {
 		if err != nil {
 			return fmt.Errorf("no input field found: %v", err)
 		}
+		return p.fill(element, description, text, true)
+	}
+
+	return p.fill(element, description, text, false)
+}
+
+// fill вводит text в поле. guessed - поле не найдено по описанию и взято первое на странице,
+// секрет в такое поле не вводится, чтобы не попасть, например, в строку поиска
+func (p *Pager) fill(element playwright.ElementHandle, description, text string, guessed bool) error {
+	if element == nil {
+		return fmt.Errorf("no input field found")
+	}
+
+	// Секреты раскрываются в последний момент и только для сайта, к которому привязаны
+	secret := false
+	if p.secrets != nil {
+		revealed, err := p.secrets.Reveal(text, p.page.URL())
+		if err != nil {
+			return err
+		}
+		secret = revealed != text
+		text = revealed
+	}
+	if secret && guessed {
+		return fmt.Errorf("field %q not found, credentials are typed only into the field given by element_id or its label", description)
 	}
 
 	if err := element.Fill(text); err != nil {
 		return fmt.Errorf("typing failed: %v", err)
 	}
+	// Не только пароли вводятся в скрытые поля, помеченное поле закрывается на снимках для модели
+	if secret {
+		if _, err := element.Evaluate("(el, attr) => el.setAttribute(attr, '')", secretAttr); err != nil {
+			return fmt.Errorf("failed to mark secret field: %v", err)
+		}
+	}
 
 	p.log.Info("Successfully typed in " + description)
 	return nil
 }
 
-func (p *Pager) ScrollPage() error {
+func (p *Pager) ScrollPage(ctx context.Context) error {
 	p.log.Info("Scrolling page")
-	_, err := p.page.Evaluate("window.scrollBy(0, 500)")
-	return err
+	return withContext(ctx, func() error {
+		_, err := p.page.Evaluate("window.scrollBy(0, 500)")
+		return err
+	})
 }
 
-func (p *Pager) Wait(seconds int) {
+func (p *Pager) Wait(ctx context.Context, seconds int) error {
 	p.log.Info(fmt.Sprintf("Waiting %d seconds", seconds))
-	time.Sleep(time.Duration(seconds) * time.Second)
+
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 func (p *Pager) CurrentURL() string {
@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// overlayID id слоя с рамками, который убирается после снимка
+const overlayID = "__agent_overlay"
+
+// secretAttr атрибут полей, в которые введены секреты из хранилища.
+// На снимках они закрыты, в том числе обычные текстовые поля, где значение видно.
+const secretAttr = "data-agent-secret"
+
+// Screenshot снимает viewport с пронумерованными рамками вокруг интерактивных элементов.
+// Номера совпадают с element_id из последнего ExtractPageState, поля с секретами закрыты.
+func (p *Pager) Screenshot(ctx context.Context) (entity.AiImage, error) {
+	return withContextValue(ctx, p.annotatedScreenshot)
+}
+
+func (p *Pager) annotatedScreenshot() (entity.AiImage, error) {
+	script := `
+    ([attr, overlayId]) => {
+        const colors = ['#e6194b', '#3cb44b', '#4363d8', '#f58231', '#911eb4', '#008080', '#9a6324', '#800000'];
+
+        const overlay = document.createElement('div');
+        overlay.id = overlayId;
+        overlay.style.cssText = 'position:fixed;left:0;top:0;width:100%;height:100%;pointer-events:none;z-index:2147483647;';
+
+        document.querySelectorAll('[' + attr + ']').forEach(el => {
+            const rect = el.getBoundingClientRect();
+            if (rect.width === 0 || rect.height === 0 ||
+                rect.bottom < 0 || rect.right < 0 ||
+                rect.top > window.innerHeight || rect.left > window.innerWidth) {
+                return;
+            }
+
+            const id = el.getAttribute(attr);
+            const color = colors[Number(id) % colors.length];
+
+            const box = document.createElement('div');
+            box.style.cssText = 'position:fixed;box-sizing:border-box;border:2px solid ' + color + ';' +
+                'left:' + rect.left + 'px;top:' + rect.top + 'px;width:' + rect.width + 'px;height:' + rect.height + 'px;';
+
+            const label = document.createElement('div');
+            label.textContent = id;
+            // Подпись над рамкой, а если места нет - внутри
+            const top = rect.top >= 16 ? rect.top - 16 : rect.top;
+            label.style.cssText = 'position:fixed;padding:0 3px;font:bold 12px/16px monospace;color:#fff;background:' + color + ';' +
+                'left:' + rect.left + 'px;top:' + top + 'px;';
+
+            overlay.appendChild(box);
+            overlay.appendChild(label);
+        });
+
+        document.documentElement.appendChild(overlay);
+    }
+    `
+
+	if _, err := p.page.Evaluate(script, []string{agentIDAttr, overlayID}); err != nil {
+		return entity.AiImage{}, fmt.Errorf("failed to annotate page: %v", err)
+	}
+	defer func() {
+		if _, err := p.page.Evaluate(`(id) => document.getElementById(id)?.remove()`, overlayID); err != nil {
+			p.log.Warn(fmt.Sprintf("failed to remove screenshot overlay: %v", err))
+		}
+	}()
+
+	screenshot, err := p.page.Screenshot(playwright.PageScreenshotOptions{
+		Type:    playwright.ScreenshotTypeJpeg,
+		Quality: playwright.Int(70),
+		Timeout: playwright.Float(10000),
+		Mask:    []playwright.Locator{p.page.Locator("[" + secretAttr + "]")},
+	})
+	if err != nil {
+		return entity.AiImage{}, fmt.Errorf("failed to take screenshot: %v", err)
+	}
+
+	return entity.AiImage{
+		MediaType: "image/jpeg",
+		Data:      screenshot,
+	}, nil
+}
@@ -1,10 +1,10 @@
 package core
 
 import (
-	"bufio"
+	"encoding/json"
 	"fmt"
 	"log/slog"
-	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,50 +14,121 @@ import (
 	"github.com/vishenosik/ai-cherry-bro/internal/agent/ai"
 	_ctx "github.com/vishenosik/ai-cherry-bro/internal/context"
 	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
 	"github.com/vishenosik/concurrency"
 	"github.com/vishenosik/gocherry/pkg/logs"
 )
 
 type Browser interface {
-	NewPage() (Page, error)
+	// NewPage открывает страницу с настройками задачи
+	NewPage(options entity.TaskOptions) (Page, error)
 }
 
 type Page interface {
-	ExtractPageState() (string, error)
-	ScrollPage() error
-	Wait(seconds int)
-	ClickElement(description string) error
-	TypeText(description string, text string) error
-	Navigate(url string) error
+	ExtractPageState(ctx context.Context, mode entity.SnapshotMode) (string, error)
+	ScrollPage(ctx context.Context) error
+	Wait(ctx context.Context, seconds int) error
+	ClickElement(ctx context.Context, elementID int, description string) error
+	TypeText(ctx context.Context, elementID int, description string, text string) error
+	// SelectOption выбирает вариант в select по значению или подписи
+	SelectOption(ctx context.Context, elementID int, description string, option string) error
+	Hover(ctx context.Context, elementID int, description string) error
+	// PressKey нажимает клавишу на элементе, а без элемента - на странице
+	PressKey(ctx context.Context, elementID int, description string, keys string) error
+	SetChecked(ctx context.Context, elementID int, description string, checked bool) error
+	// UploadFile прикрепляет файл из каталога загрузок к полю выбора файла
+	UploadFile(ctx context.Context, elementID int, description string, file string) error
+	Navigate(ctx context.Context, url string) error
+	GoBack(ctx context.Context) error
+	// DescribeElement сведения об элементе для политики безопасности,
+	// без номера и описания - об элементе в фокусе
+	DescribeElement(ctx context.Context, elementID int, description string) (entity.Element, error)
+	// AuthSignals признаки входа в аккаунт на текущей странице
+	AuthSignals(ctx context.Context) (entity.AuthSignals, error)
+	// Screenshot снимок viewport с номерами элементов для vision режима
+	Screenshot(ctx context.Context) (entity.AiImage, error)
+	CurrentURL() string
 	Close() error
 }
 
 type AiClient interface {
-	Call(messages []entity.AiMessage) (*entity.AiResponse, error)
+	Call(ctx context.Context, messages []entity.AiMessage) (*entity.AiResponse, error)
 }
 
 type ContextManager interface {
 	AddToHistory(action string)
+	// FitPrompt собирает промпт в пределах бюджета токенов
+	FitPrompt(build _ctx.PromptBuilder, pageState string) []entity.AiMessage
 	CheckAuthRequired(task string, currentURL string) bool
 	ClearHistory()
-	GetAuthState(domain string) *_ctx.AuthState
+	GetAuthState(domain string) *entity.AuthState
 	GetHistory() string
-	UpdateAuthState(url string, isLoggedIn bool, username string)
+	// UpdateAuthState обновляет состояние авторизации домена по признакам страницы
+	UpdateAuthState(url string, signals entity.AuthSignals) (entity.AuthState, bool)
 }
 
 type Security interface {
-	CheckAction(action string, target string, reasoning string) bool
+	CheckAction(ctx context.Context, task entity.PoolTask, step entity.TaskStep) (entity.ActionVerdict, error)
+	RequestUserAction(ctx context.Context, taskID string, step entity.TaskStep) (bool, error)
+	// ScanContent ищет в содержимом страницы признаки prompt injection
+	ScanContent(content string) []entity.InjectionFinding
+	// SecretRefs плейсхолдеры учётных данных, которые модель может вводить
+	SecretRefs() []entity.SecretRef
+	// Redact скрывает значения секретов в тексте
+	Redact(text string) string
+	// ForgetTask освобождает состояние завершённой задачи
+	ForgetTask(taskID string)
+}
+
+// TaskTracker получает сведения о ходе выполнения задач
+type TaskTracker interface {
+	TaskStarted(id string)
+	TaskStep(id string, step entity.TaskStep)
+	TaskFinished(id string, result entity.TaskResult)
+}
+
+// ContextManagerFactory создаёт отдельный менеджер контекста для каждой задачи
+type ContextManagerFactory func(task entity.PoolTask) ContextManager
+
+type Config struct {
+	// Workers число задач, выполняемых одновременно
+	Workers int `env:"AGENT_WORKERS" env-default:"1"`
+	// SnapshotMode представление страницы для задач без своего режима
+	SnapshotMode entity.SnapshotMode `env:"AGENT_SNAPSHOT_MODE" env-default:"dom"`
+	// Vision прикладывать скриншоты ко всем задачам
+	Vision bool `env:"AGENT_VISION" env-default:"false"`
+	// MaxSteps шагов на задачу, после них задача завершается со статусом step_limit
+	MaxSteps int `env:"AGENT_MAX_STEPS" env-default:"50"`
+	// StepDelay пауза между действиями, чтобы страница успела обновиться
+	StepDelay time.Duration `env:"AGENT_STEP_DELAY" env-default:"2s"`
+}
+
+func (conf Config) Validate() error {
+	if conf.Workers < 1 {
+		return errors.Errorf("invalid workers count: %d", conf.Workers)
+	}
+	if !conf.SnapshotMode.IsValid() {
+		return errors.Errorf("invalid snapshot mode: %q", conf.SnapshotMode)
+	}
+	if conf.MaxSteps < 1 {
+		return errors.Errorf("invalid max steps: %d", conf.MaxSteps)
+	}
+	if conf.StepDelay < 0 {
+		return errors.Errorf("invalid step delay: %v", conf.StepDelay)
+	}
+	return nil
 }
 
 type Orchestrator struct {
-	browser        Browser
-	page           Page
-	aiClient       AiClient
-	contextManager ContextManager
-	securityLayer  Security
-	isRunning      bool
-	currentTask    string
-	maxSteps       int
+	browser           Browser
+	aiClient          AiClient
+	newContextManager ContextManagerFactory
+	securityLayer     Security
+	tracker           TaskTracker
+	maxSteps          int
+	stepDelay         time.Duration
+	snapshotMode      entity.SnapshotMode
+	vision            bool
 
 	log     *slog.Logger
 	pool    *concurrency.Pool
@@ -65,35 +136,40 @@ type Orchestrator struct {
 }
 
 func NewOrchestrator(
+	conf Config,
 	browser Browser,
 	aiClient AiClient,
-	contextManager ContextManager,
+	newContextManager ContextManagerFactory,
 	securityLayer Security,
+	tracker TaskTracker,
 	subscriptions ...chan entity.PoolTask,
 ) (*Orchestrator, error) {
 
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &Orchestrator{
-		browser:        browser,
-		aiClient:       aiClient,
-		contextManager: contextManager,
-		securityLayer:  securityLayer,
-		maxSteps:       50,
+		browser:           browser,
+		aiClient:          aiClient,
+		newContextManager: newContextManager,
+		securityLayer:     securityLayer,
+		tracker:           tracker,
+		maxSteps:          conf.MaxSteps,
+		stepDelay:         conf.StepDelay,
+		snapshotMode:      conf.SnapshotMode,
+		vision:            conf.Vision,
 
 		log: logs.SetupLogger().With(logs.AppComponent("core_orchestrator")),
 
-		pool:    concurrency.NewWorkerPool(concurrency.WithWorkersControl(1, 1, 1)),
+		pool: concurrency.NewWorkerPool(
+			concurrency.WithWorkersControl(conf.Workers, conf.Workers, conf.Workers),
+		),
 		subChan: concurrency.MergeChannels(context.Background(), uint16(1024), subscriptions...),
 	}, nil
 }
 
 func (o *Orchestrator) Start(ctx context.Context) error {
-	page, err := o.browser.NewPage()
-	if err != nil {
-		return err
-	}
-
-	o.page = page
-
 	if err := o.startPool(ctx); err != nil {
 		return err
 	}
@@ -108,34 +184,128 @@ func (o *Orchestrator) Stop(ctx context.Context) error {
 	return nil
 }
 
-func (o *Orchestrator) RunTask(task string) {
-	o.currentTask = task
-	o.isRunning = true
+func (o *Orchestrator) RunTask(task entity.PoolTask) {
+	ctx := task.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Задачу отменили, пока она стояла в очереди
+	if ctx.Err() != nil {
+		o.log.Info("task cancelled before start", slog.String("task_id", task.ID))
+		return
+	}
+
+	ctx = ai.WithModel(ctx, task.Options.Model)
+
+	o.tracker.TaskStarted(task.ID)
+	defer o.securityLayer.ForgetTask(task.ID)
+
+	run := o.startRun(task)
+	if run.answerSchema != nil {
+		ctx = ai.WithAnswerSchema(ctx, run.answerSchema)
+	}
+
+	result := run.runTask(ctx, task)
+	o.tracker.TaskFinished(task.ID, result)
+}
+
+// taskRun состояние одной задачи: своя страница и своя история
+type taskRun struct {
+	*Orchestrator
+
+	page           Page
+	contextManager ContextManager
+	snapshotMode   entity.SnapshotMode
+	vision         bool
+	log            *slog.Logger
+
+	// answerSchema схема ответа задачи, result - последний принятый ответ
+	answerSchema jsonschema.Schema
+	result       json.RawMessage
+}
+
+func (o *Orchestrator) startRun(task entity.PoolTask) *taskRun {
+	snapshotMode := task.Options.SnapshotMode
+	if snapshotMode == "" {
+		snapshotMode = o.snapshotMode
+	}
+
+	// Схема проверена при создании задачи
+	var answerSchema jsonschema.Schema
+	if len(task.Options.AnswerSchema) > 0 {
+		answerSchema, _ = jsonschema.Parse(task.Options.AnswerSchema)
+	}
+
+	return &taskRun{
+		Orchestrator:   o,
+		answerSchema:   answerSchema,
+		contextManager: o.newContextManager(task),
+		snapshotMode:   snapshotMode,
+		vision:         o.vision || task.Options.Vision,
+		log:            o.log.With(slog.String("task_id", task.ID)),
+	}
+}
 
-	o.log.Info("starting task",
-		slog.String("task", task),
-		slog.Int("max_steps", o.maxSteps),
+func (r *taskRun) runTask(ctx context.Context, task entity.PoolTask) entity.TaskResult {
+	log := r.log
+
+	page, err := r.browser.NewPage(task.Options)
+	if err != nil {
+		log.Error("failed to open page", logs.Error(err))
+		return failed(ctx, errors.Wrap(err, "failed to open page"))
+	}
+	defer func() {
+		if err := page.Close(); err != nil {
+			log.Warn("failed to close page", logs.Error(err))
+		}
+	}()
+
+	r.page = page
+
+	log.Info("starting task",
+		slog.String("task", task.Text),
+		slog.String("model", task.Options.Model),
+		slog.String("snapshot_mode", string(r.snapshotMode)),
+		slog.Bool("vision", r.vision),
+		slog.Int("max_steps", r.maxSteps),
 	)
 
-	for step := 1; step <= o.maxSteps && o.isRunning; step++ {
+	// Задача, прерванная перезапуском, продолжает нумерацию и общий лимит шагов
+	for step := task.Steps + 1; step <= r.maxSteps; step++ {
 
 		// Получаем текущее состояние страницы
-		pageState, err := o.page.ExtractPageState()
+		pageState, err := r.page.ExtractPageState(ctx, r.snapshotMode)
 		if err != nil {
-			o.log.Error("Failed to extract page state", logs.Error(err))
-			break
+			log.Error("Failed to extract page state", logs.Error(err))
+			return failed(ctx, errors.Wrap(err, "failed to extract page state"))
+		}
+		// Введённые агентом секреты видны в полях страницы
+		pageState = r.securityLayer.Redact(pageState)
+
+		r.updateAuthState(ctx)
+
+		// Текст страницы недоверенный, инструкции в нём помечаем для модели и шага
+		injection := r.securityLayer.ScanContent(pageState)
+		if len(injection) > 0 {
+			log.Warn("possible prompt injection on page",
+				slog.String("page_url", r.page.CurrentURL()),
+				slog.String("pattern", injection[0].Pattern),
+				slog.String("snippet", injection[0].Snippet),
+			)
 		}
 
 		// Решаем следующее действие
-		action, err := o.decideNextAction(task, pageState, o.contextManager.GetHistory())
+		action, err := r.decideNextAction(ctx, task.Text, pageState, injection, r.screenshot(ctx))
 		if err != nil {
-			o.log.Error("failed to decide action", logs.Error(err))
-			break
+			log.Error("failed to decide action", logs.Error(err))
+			return failed(ctx, errors.Wrap(err, "failed to decide action"))
 		}
 
-		log := o.log.With(
+		log := log.With(
 			slog.String("action", action.Action),
 			slog.String("target", action.Target),
+			slog.Int("element_id", action.ElementID),
 		)
 
 		log.Info("acting",
@@ -143,80 +313,344 @@ func (o *Orchestrator) RunTask(task string) {
 			slog.String("reasoning", action.Reasoning),
 		)
 
-		// Проверка безопасности для чувствительных действий
-		if !o.securityLayer.CheckAction(action.Action, action.Target, action.Reasoning) {
-			log.Error("action cancelled by user")
-			break
+		stepInfo := entity.TaskStep{
+			Number:  step,
+			PageURL: r.page.CurrentURL(),
+			Action:  *action,
+			Element: r.describeElement(ctx, action),
+
+			Injection: injection,
 		}
 
-		// Выполняем действие
-		if err := o.executeAction(action); err != nil {
-			log.Error("action failed", logs.Error(err))
+		result, done := r.doStep(ctx, log, task, action, &stepInfo)
+		r.tracker.TaskStep(task.ID, r.redactStep(stepInfo))
+		if done {
+			return result
+		}
 
-			// Пробуем восстановиться
-			if !o.handleError(err, action) {
-				break
-			}
+		// Пауза между действиями
+		select {
+		case <-ctx.Done():
+			log.Info("task cancelled")
+			return cancelled()
+		case <-time.After(r.stepDelay):
 		}
+	}
+
+	log.Warn("maximum steps reached. task may not be complete")
+	return entity.TaskResult{
+		Status: entity.TaskStatusStepLimit,
+		Error:  fmt.Sprintf("maximum steps reached (%d)", r.maxSteps),
+	}
+}
+
+// doStep проверяет и выполняет действие, заполняя stepInfo.
+// Возвращает done = true, если задача завершена.
+func (r *taskRun) doStep(
+	ctx context.Context,
+	log *slog.Logger,
+	task entity.PoolTask,
+	action *entity.AiResponse,
+	stepInfo *entity.TaskStep,
+) (result entity.TaskResult, done bool) {
+	taskID := task.ID
+
+	// Проверка действия политикой безопасности
+	verdict, err := r.securityLayer.CheckAction(ctx, task, *stepInfo)
+	if err != nil {
+		log.Error("security check failed", logs.Error(err))
+		return failed(ctx, errors.Wrap(err, "security check failed")), true
+	}
 
-		// Добавляем в историю
-		o.contextManager.AddToHistory(fmt.Sprintf("%s: %s -> %s", action.Action, action.Target, action.Reasoning))
+	stepInfo.Approved = verdict.Approved
+	stepInfo.PolicyOutcome = verdict.Outcome
+	stepInfo.PolicyRule = verdict.Rule
+	stepInfo.PolicyDisagreement = verdict.Disagreement
+
+	// Запрещённое действие не выполняется, модель выберет другое
+	if verdict.Outcome == entity.PolicyOutcomeDeny {
+		log.Warn("action denied by policy", slog.String("rule", verdict.Rule))
+		stepInfo.Error = deniedMessage(verdict)
+		r.contextManager.AddToHistory(fmt.Sprintf("%s: %s -> %s", action.Action, historyTarget(action), stepInfo.Error))
+		return entity.TaskResult{}, false
+	}
 
-		// Проверяем завершение
-		if action.Completed {
-			o.log.Info("task completed successfully")
-			break
+	if !stepInfo.Approved {
+		reason := "action cancelled by user"
+		if verdict.Reason != "" {
+			reason = "action not approved: " + verdict.Reason
 		}
+		log.Error(reason)
+		return entity.TaskResult{
+			Status: entity.TaskStatusCancelled,
+			Error:  reason,
+		}, true
+	}
 
-		// Пауза между действиями
-		time.Sleep(2 * time.Second)
+	// Ответ должен соответствовать схеме задачи, иначе модель исправит его на следующем шаге
+	if action.Action == "extract" || action.Action == "complete" || action.Completed {
+		if err := r.checkAnswer(action); err != nil {
+			log.Warn("answer rejected", logs.Error(err))
+			stepInfo.Error = err.Error()
+			r.contextManager.AddToHistory(fmt.Sprintf("%s rejected: %v", action.Action, err))
+			return entity.TaskResult{}, false
+		}
+	}
+
+	// Выполняем действие
+	if err := r.executeAction(ctx, taskID, action); err != nil {
+		if ctx.Err() != nil {
+			return cancelled(), true
+		}
+		isRejected := rejected(err)
+		err = errors.New(r.securityLayer.Redact(err.Error()))
+
+		// Запрещённый переход или секрет не на своём сайте: действие не выполнено,
+		// модель выберет другой путь
+		if isRejected {
+			log.Warn("action rejected", logs.Error(err))
+			stepInfo.Error = err.Error()
+			r.contextManager.AddToHistory(fmt.Sprintf("%s: %s -> %s", action.Action, historyTarget(action), err))
+			return entity.TaskResult{}, false
+		}
+
+		log.Error("action failed", logs.Error(err))
+		stepInfo.Error = err.Error()
+
+		// Пробуем восстановиться
+		stepInfo.Recovery, stepInfo.Recovered = r.handleError(ctx, err, action)
+		if !stepInfo.Recovered {
+			return failed(ctx, err), true
+		}
+	}
+
+	// Добавляем в историю
+	r.contextManager.AddToHistory(fmt.Sprintf("%s: %s -> %s", action.Action, historyTarget(action), action.Reasoning))
+
+	// Проверяем завершение
+	if action.Completed || action.Action == "complete" {
+		log.Info("task completed successfully")
+		return entity.TaskResult{
+			Status:    entity.TaskStatusSucceeded,
+			Answer:    action.Text,
+			Reasoning: action.Reasoning,
+			Data:      r.result,
+		}, true
+	}
+
+	return entity.TaskResult{}, false
+}
+
+// checkAnswer проверяет data по схеме задачи и запоминает принятый ответ.
+// complete без data завершает задачу с ответом последнего extract.
+func (r *taskRun) checkAnswer(action *entity.AiResponse) error {
+	data := action.Data
+	if len(data) == 0 && action.Action != "extract" {
+		data = r.result
+	}
+
+	if r.answerSchema != nil {
+		if len(data) == 0 {
+			return errors.New("data is required by the answer schema")
+		}
+		if err := jsonschema.ValidateJSON(r.answerSchema, data); err != nil {
+			return errors.Wrap(err, "data does not match the answer schema")
+		}
+	}
+
+	r.result = data
+	return nil
+}
+
+func deniedMessage(verdict entity.ActionVerdict) string {
+	if verdict.Rule == "" {
+		return "denied by security policy"
+	}
+	return fmt.Sprintf("denied by security policy rule %q", verdict.Rule)
+}
+
+// elementActions действия над элементом страницы
+var elementActions = []string{"click", "type", "select", "hover", "press_key", "check", "upload"}
+
+// describeElement сведения об элементе действия, если они нужны и доступны
+func (r *taskRun) describeElement(ctx context.Context, action *entity.AiResponse) *entity.Element {
+	if !slices.Contains(elementActions, action.Action) {
+		return nil
+	}
+	// press_key без элемента нажимает клавишу на элементе в фокусе, его и описываем
+	if action.Action != "press_key" && action.ElementID <= 0 && action.Target == "" {
+		return nil
+	}
+
+	element, err := r.page.DescribeElement(ctx, action.ElementID, action.Target)
+	if err != nil {
+		// Политика проверит действие без сведений об элементе
+		r.log.Warn("failed to describe element", slog.Int("element_id", action.ElementID), logs.Error(err))
+		return nil
+	}
+	return &element
+}
+
+// rejected ошибки, о которых сообщается модели без попытки восстановления
+func rejected(err error) bool {
+	var domainErr *entity.DomainError
+	return errors.As(err, &domainErr) ||
+		errors.Is(err, entity.ErrSecretNotAllowed) ||
+		errors.Is(err, entity.ErrSecretNotFound)
+}
 
-		if step == o.maxSteps {
-			o.log.Warn("maximum steps reached. task may not be complete")
+// redactStep скрывает секреты в сохраняемом шаге
+func (r *taskRun) redactStep(step entity.TaskStep) entity.TaskStep {
+	step.Action.Text = r.securityLayer.Redact(step.Action.Text)
+	step.Action.Reasoning = r.securityLayer.Redact(step.Action.Reasoning)
+	step.Error = r.securityLayer.Redact(step.Error)
+	if step.Element != nil {
+		element := *step.Element
+		element.Text = r.securityLayer.Redact(element.Text)
+		step.Element = &element
+	}
+	return step
+}
+
+// historyTarget описание цели действия для истории
+func historyTarget(action *entity.AiResponse) string {
+	target := action.Target
+	if action.ElementID > 0 {
+		target = fmt.Sprintf("[%d] %s", action.ElementID, action.Target)
+	}
+
+	// Аргумент действия, иначе в истории не видно, что уже выбрано или нажато
+	switch action.Action {
+	case "select":
+		target += fmt.Sprintf(" = %q", action.Option)
+	case "press_key":
+		target += " " + action.Keys
+	case "upload":
+		target += " <- " + action.File
+	case "check":
+		if !action.IsChecked() {
+			target += " (uncheck)"
 		}
 	}
+	return strings.TrimSpace(target)
+}
 
-	o.isRunning = false
+// failed считает ошибку отменой, если контекст задачи уже отменён
+func failed(ctx context.Context, err error) entity.TaskResult {
+	if ctx.Err() != nil {
+		return cancelled()
+	}
+	return entity.TaskResult{
+		Status: entity.TaskStatusFailed,
+		Error:  err.Error(),
+	}
+}
+
+func cancelled() entity.TaskResult {
+	return entity.TaskResult{
+		Status: entity.TaskStatusCancelled,
+		Error:  "task cancelled",
+	}
 }
 
-func (o *Orchestrator) decideNextAction(task, pageState, history string) (*entity.AiResponse, error) {
-	messages := ai.BuildDecisionPrompt(task, pageState, history)
-	return o.aiClient.Call(messages)
+// updateAuthState определяет по странице, выполнен ли вход на текущем домене,
+// и сохраняет состояние для подсказки модели
+func (r *taskRun) updateAuthState(ctx context.Context) {
+	signals, err := r.page.AuthSignals(ctx)
+	if err != nil {
+		r.log.Warn("failed to detect auth state", logs.Error(err))
+		return
+	}
+	// Имя пользователя может совпадать с секретом из хранилища
+	signals.Username = r.securityLayer.Redact(signals.Username)
+
+	state, changed := r.contextManager.UpdateAuthState(r.page.CurrentURL(), signals)
+	if changed {
+		r.log.Info("auth state changed",
+			slog.String("domain", state.Domain),
+			slog.Bool("logged_in", state.IsLoggedIn),
+			slog.Bool("auth_required", state.AuthRequired),
+			slog.String("reason", state.Reason),
+		)
+	}
 }
 
-func (o *Orchestrator) executeAction(action *entity.AiResponse) error {
+// screenshot снимает страницу в vision режиме.
+// Без скриншота задача продолжается с текстовым состоянием.
+func (r *taskRun) screenshot(ctx context.Context) []entity.AiImage {
+	if !r.vision {
+		return nil
+	}
+
+	image, err := r.page.Screenshot(ctx)
+	if err != nil {
+		r.log.Warn("failed to take screenshot", logs.Error(err))
+		return nil
+	}
+	return []entity.AiImage{image}
+}
+
+func (r *taskRun) decideNextAction(
+	ctx context.Context,
+	task, pageState string,
+	injection []entity.InjectionFinding,
+	images []entity.AiImage,
+) (*entity.AiResponse, error) {
+	pageURL := r.page.CurrentURL()
+	authHint := ai.AuthHint(
+		r.contextManager.GetAuthState(_ctx.Domain(pageURL)),
+		r.contextManager.CheckAuthRequired(task, pageURL),
+	)
+
+	messages := r.contextManager.FitPrompt(func(pageState, history string) []entity.AiMessage {
+		messages := ai.BuildDecisionPrompt(task, pageState, history)
+		messages[len(messages)-1].Content += authHint
+		messages[len(messages)-1].Content += ai.CredentialsHint(r.securityLayer.SecretRefs())
+		messages[len(messages)-1].Content += ai.InjectionWarning(injection)
+		// Скриншот идёт вместе с текстовым состоянием страницы
+		messages[len(messages)-1].Images = images
+		return messages
+	}, pageState)
+	return r.aiClient.Call(ctx, messages)
+}
+
+func (r *taskRun) executeAction(ctx context.Context, taskID string, action *entity.AiResponse) error {
 	switch action.Action {
 	case "click":
-		return o.page.ClickElement(action.Target)
+		return r.page.ClickElement(ctx, action.ElementID, action.Target)
 	case "type":
-		return o.page.TypeText(action.Target, action.Text)
+		return r.page.TypeText(ctx, action.ElementID, action.Target, action.Text)
+	case "select":
+		return r.page.SelectOption(ctx, action.ElementID, action.Target, action.Option)
+	case "hover":
+		return r.page.Hover(ctx, action.ElementID, action.Target)
+	case "press_key":
+		return r.page.PressKey(ctx, action.ElementID, action.Target, action.Keys)
+	case "check":
+		return r.page.SetChecked(ctx, action.ElementID, action.Target, action.IsChecked())
+	case "upload":
+		return r.page.UploadFile(ctx, action.ElementID, action.Target, action.File)
 	case "navigate":
-		return o.page.Navigate(action.URL)
+		return r.page.Navigate(ctx, action.URL)
+	case "go_back":
+		return r.page.GoBack(ctx)
 	case "scroll":
-		return o.page.ScrollPage()
+		return r.page.ScrollPage(ctx)
 	case "wait":
-		o.page.Wait(3)
-		return nil
-	case "complete":
+		return r.page.Wait(ctx, 3)
+	case "complete", "extract":
 		return nil
 	case "wait_user":
-		o.log.Info("waiting for user interaction")
-
-		fmt.Printf("\n🚨 AUTHENTICATION REQUIRED 🚨\n")
-		fmt.Printf("Reason: %s\n", action.Reasoning)
-		fmt.Printf("Current URL: %s\n", action.URL)
-		fmt.Printf("\nPlease manually authenticate in the browser and then:\n")
-		fmt.Printf("1. Complete the login process\n")
-		fmt.Printf("2. Return to the relevant page\n")
-		fmt.Printf("3. Press Enter here to continue...\n\n")
-		fmt.Print("Do you want to proceed? (y/n): ")
-
-		scanner := bufio.NewScanner(os.Stdin)
-		if scanner.Scan() {
-			response := strings.TrimSpace(scanner.Text())
-			if strings.ToLower(response) == "y" {
-				return nil
-			}
+		r.log.Info("waiting for user interaction")
+
+		approved, err := r.securityLayer.RequestUserAction(ctx, taskID, entity.TaskStep{
+			PageURL: r.page.CurrentURL(),
+			Action:  *action,
+		})
+		if err != nil {
+			return err
+		}
+		if !approved {
 			return errors.New("user didn't authenticate")
 		}
 		return nil
@@ -225,32 +659,35 @@ func (o *Orchestrator) executeAction(action *entity.AiResponse) error {
 	}
 }
 
-func (o *Orchestrator) handleError(
+// handleError пытается восстановиться после ошибки действия.
+// Возвращает применённую стратегию и признак успешного восстановления.
+func (r *taskRun) handleError(
+	ctx context.Context,
 	err error,
 	_ *entity.AiResponse,
-) bool {
+) (string, bool) {
 	errorMsg := err.Error()
-	o.log.Warn("handling error", logs.Error(err))
+	r.log.Warn("handling error", logs.Error(err))
 
 	// Стратегии восстановления
 	switch {
 	case strings.Contains(errorMsg, "element not found"):
-		o.log.Error("Element not found, trying to scroll...")
-		o.page.ScrollPage()
-		return true
+		r.log.Error("Element not found, trying to scroll...")
+		r.page.ScrollPage(ctx)
+		return "scroll", true
 
 	case strings.Contains(errorMsg, "not visible"):
-		o.log.Error("Element not visible, scrolling to view...")
-		o.page.ScrollPage()
-		return true
+		r.log.Error("Element not visible, scrolling to view...")
+		r.page.ScrollPage(ctx)
+		return "scroll", true
 
 	case strings.Contains(errorMsg, "navigation"):
-		o.log.Error("Navigation issue, waiting...")
-		o.page.Wait(5)
-		return true
+		r.log.Error("Navigation issue, waiting...")
+		r.page.Wait(ctx, 5)
+		return "wait", true
 
 	default:
-		o.log.Error("❌ Unrecoverable error")
-		return false
+		r.log.Error("❌ Unrecoverable error")
+		return "", false
 	}
 }
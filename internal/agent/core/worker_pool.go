@@ -27,7 +27,7 @@ func (o *Orchestrator) startPool(ctx context.Context) error {
 				concurrency.Task{
 					ID: task.ID,
 					Func: func() {
-						o.RunTask(task.Text)
+						o.RunTask(task)
 					},
 					Priority: concurrency.Priority(0),
 				},
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+
+	browser_task_v1 "github.com/vishenosik/ai-cherry-bro/gen/grpc/v1/browser_task"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+type ApprovalUsecase interface {
+	ListPending(ctx context.Context, task_id string) []entity.Approval
+	Resolve(ctx context.Context, approval_id string, approved bool, comment string) (entity.Approval, error)
+}
+
+func (bsa *BrowserServiceApi) ListPendingApprovals(ctx context.Context, req *browser_task_v1.ListPendingApprovalsReq) (*browser_task_v1.ListPendingApprovalsResp, error) {
+	approvals := bsa.approvals.ListPending(ctx, req.TaskId)
+
+	resp := &browser_task_v1.ListPendingApprovalsResp{
+		Approvals: make([]*browser_task_v1.Approval, 0, len(approvals)),
+	}
+	for _, approval := range approvals {
+		resp.Approvals = append(resp.Approvals, approvalToProto(approval))
+	}
+	return resp, nil
+}
+
+func (bsa *BrowserServiceApi) ResolveApproval(ctx context.Context, req *browser_task_v1.ResolveApprovalReq) (*browser_task_v1.ResolveApprovalResp, error) {
+	approval, err := bsa.approvals.Resolve(ctx, req.ApprovalId, req.Approved, req.Comment)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &browser_task_v1.ResolveApprovalResp{
+		Approval: approvalToProto(approval),
+	}, nil
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"time"
+
+	browser_task_v1 "github.com/vishenosik/ai-cherry-bro/gen/grpc/v1/browser_task"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var taskStatuses = map[entity.TaskStatus]browser_task_v1.TaskStatus{
+	entity.TaskStatusQueued:    browser_task_v1.TaskStatus_TASK_STATUS_QUEUED,
+	entity.TaskStatusRunning:   browser_task_v1.TaskStatus_TASK_STATUS_RUNNING,
+	entity.TaskStatusSucceeded: browser_task_v1.TaskStatus_TASK_STATUS_SUCCEEDED,
+	entity.TaskStatusFailed:    browser_task_v1.TaskStatus_TASK_STATUS_FAILED,
+	entity.TaskStatusCancelled: browser_task_v1.TaskStatus_TASK_STATUS_CANCELLED,
+	entity.TaskStatusStepLimit: browser_task_v1.TaskStatus_TASK_STATUS_STEP_LIMIT,
+}
+
+var snapshotModes = map[entity.SnapshotMode]browser_task_v1.SnapshotMode{
+	entity.SnapshotModeDOM:           browser_task_v1.SnapshotMode_SNAPSHOT_MODE_DOM,
+	entity.SnapshotModeAccessibility: browser_task_v1.SnapshotMode_SNAPSHOT_MODE_ACCESSIBILITY,
+}
+
+// snapshotModeFromProto возвращает пустой режим для UNSPECIFIED
+func snapshotModeFromProto(mode browser_task_v1.SnapshotMode) (entity.SnapshotMode, bool) {
+	if mode == browser_task_v1.SnapshotMode_SNAPSHOT_MODE_UNSPECIFIED {
+		return "", true
+	}
+	for m, pm := range snapshotModes {
+		if pm == mode {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+var browserEngines = map[entity.BrowserEngine]browser_task_v1.BrowserEngine{
+	entity.BrowserEngineChromium: browser_task_v1.BrowserEngine_BROWSER_ENGINE_CHROMIUM,
+	entity.BrowserEngineFirefox:  browser_task_v1.BrowserEngine_BROWSER_ENGINE_FIREFOX,
+	entity.BrowserEngineWebKit:   browser_task_v1.BrowserEngine_BROWSER_ENGINE_WEBKIT,
+}
+
+// browserOptionsFromProto переводит настройки браузера задачи, проверка - в entity.BrowserOptions.Validate
+func browserOptionsFromProto(options *browser_task_v1.BrowserOptions) (entity.BrowserOptions, bool) {
+	if options == nil {
+		return entity.BrowserOptions{}, true
+	}
+
+	result := entity.BrowserOptions{
+		Headless:          options.Headless,
+		Device:            options.Device,
+		UserAgent:         options.UserAgent,
+		Locale:            options.Locale,
+		Timezone:          options.Timezone,
+		Proxy:             options.Proxy,
+		Headers:           options.Headers,
+		NavigationTimeout: time.Duration(options.NavigationTimeoutMs) * time.Millisecond,
+	}
+
+	if options.Engine != browser_task_v1.BrowserEngine_BROWSER_ENGINE_UNSPECIFIED {
+		for engine, pe := range browserEngines {
+			if pe == options.Engine {
+				result.Engine = engine
+			}
+		}
+		if result.Engine == "" {
+			return result, false
+		}
+	}
+	if options.Viewport != nil {
+		result.Viewport = &entity.Viewport{
+			Width:  int(options.Viewport.Width),
+			Height: int(options.Viewport.Height),
+		}
+	}
+	if g := options.Geolocation; g != nil {
+		result.Geolocation = &entity.Geolocation{
+			Latitude:  g.Latitude,
+			Longitude: g.Longitude,
+			Accuracy:  g.Accuracy,
+		}
+	}
+	return result, true
+}
+
+// browserOptionsToProto настройки браузера задачи без значений заголовков и пароля прокси
+func browserOptionsToProto(options entity.BrowserOptions) *browser_task_v1.BrowserOptions {
+	options = options.Redacted()
+	result := &browser_task_v1.BrowserOptions{
+		Headless:            options.Headless,
+		Engine:              browserEngines[options.Engine],
+		Device:              options.Device,
+		UserAgent:           options.UserAgent,
+		Locale:              options.Locale,
+		Timezone:            options.Timezone,
+		Proxy:               options.Proxy,
+		Headers:             options.Headers,
+		NavigationTimeoutMs: int32(options.NavigationTimeout.Milliseconds()),
+	}
+	if options.Viewport != nil {
+		result.Viewport = &browser_task_v1.Viewport{
+			Width:  int32(options.Viewport.Width),
+			Height: int32(options.Viewport.Height),
+		}
+	}
+	if g := options.Geolocation; g != nil {
+		result.Geolocation = &browser_task_v1.Geolocation{
+			Latitude:  g.Latitude,
+			Longitude: g.Longitude,
+			Accuracy:  g.Accuracy,
+		}
+	}
+	return result
+}
+
+func taskToProto(task entity.Task) *browser_task_v1.Task {
+	return &browser_task_v1.Task{
+		TaskId:         task.ID,
+		TaskText:       task.Text,
+		Model:          task.Options.Model,
+		SnapshotMode:   snapshotModes[task.Options.SnapshotMode],
+		Vision:         task.Options.Vision,
+		Restarts:       int32(task.Restarts),
+		AnswerSchema:   string(task.Options.AnswerSchema),
+		Policy:         task.Options.Policy,
+		AllowedDomains: task.Options.Domains.Allowed,
+		BlockedDomains: task.Options.Domains.Blocked,
+		Profile:        task.Options.Profile,
+		Browser:        browserOptionsToProto(task.Options.Browser),
+		Result:         string(task.Result),
+		Status:         taskStatuses[task.Status],
+		Steps:          int32(task.Steps),
+		LastAction:     actionToProto(task.LastAction),
+		Answer:         task.Answer,
+		Reasoning:      task.Reasoning,
+		Error:          task.Error,
+		CreatedAt:      timestamppb.New(task.CreatedAt),
+		UpdatedAt:      timestamppb.New(task.UpdatedAt),
+	}
+}
+
+func actionToProto(action *entity.AiResponse) *browser_task_v1.Action {
+	if action == nil {
+		return nil
+	}
+	return &browser_task_v1.Action{
+		Action:       action.Action,
+		Target:       action.Target,
+		Text:         action.Text,
+		Url:          action.URL,
+		Reasoning:    action.Reasoning,
+		NeedApproval: action.NeedApproval,
+		Data:         string(action.Data),
+		Option:       action.Option,
+		Keys:         action.Keys,
+		File:         action.File,
+		Checked:      action.Checked,
+	}
+}
+
+func taskEventToProto(event entity.TaskEvent) *browser_task_v1.TaskEvent {
+	resp := &browser_task_v1.TaskEvent{
+		TaskId: event.TaskID,
+		Seq:    int32(event.Seq),
+		Time:   timestamppb.New(event.Time),
+	}
+
+	switch event.Type {
+	case entity.TaskEventStatus:
+		status := &browser_task_v1.StatusEvent{
+			Status: taskStatuses[event.Status],
+		}
+		if event.Result != nil {
+			status.Answer = event.Result.Answer
+			status.Reasoning = event.Result.Reasoning
+			status.Error = event.Result.Error
+			status.Result = string(event.Result.Data)
+		}
+		resp.Event = &browser_task_v1.TaskEvent_Status{Status: status}
+
+	case entity.TaskEventStep:
+		step := event.Step
+		resp.Event = &browser_task_v1.TaskEvent_Step{Step: &browser_task_v1.StepEvent{
+			Step:      int32(step.Number),
+			PageUrl:   step.PageURL,
+			Action:    actionToProto(&step.Action),
+			Approved:  step.Approved,
+			Error:     step.Error,
+			Recovery:  step.Recovery,
+			Recovered: step.Recovered,
+
+			PolicyOutcome:      policyOutcomes[step.PolicyOutcome],
+			PolicyRule:         step.PolicyRule,
+			PolicyDisagreement: policyDisagreements[step.PolicyDisagreement],
+			Injection:          injectionToProto(step.Injection),
+		}}
+
+	case entity.TaskEventApproval:
+		resp.Event = &browser_task_v1.TaskEvent_Approval{
+			Approval: approvalToProto(*event.Approval),
+		}
+	}
+
+	return resp
+}
+
+func injectionToProto(findings []entity.InjectionFinding) []*browser_task_v1.InjectionFinding {
+	if len(findings) == 0 {
+		return nil
+	}
+
+	result := make([]*browser_task_v1.InjectionFinding, 0, len(findings))
+	for _, finding := range findings {
+		result = append(result, &browser_task_v1.InjectionFinding{
+			Pattern: finding.Pattern,
+			Snippet: finding.Snippet,
+		})
+	}
+	return result
+}
+
+var policyOutcomes = map[entity.PolicyOutcome]browser_task_v1.PolicyOutcome{
+	entity.PolicyOutcomeAllow:           browser_task_v1.PolicyOutcome_POLICY_OUTCOME_ALLOW,
+	entity.PolicyOutcomeRequireApproval: browser_task_v1.PolicyOutcome_POLICY_OUTCOME_REQUIRE_APPROVAL,
+	entity.PolicyOutcomeDeny:            browser_task_v1.PolicyOutcome_POLICY_OUTCOME_DENY,
+}
+
+var policyDisagreements = map[entity.PolicyDisagreement]browser_task_v1.PolicyDisagreement{
+	entity.PolicyDisagreementModelOnly:  browser_task_v1.PolicyDisagreement_POLICY_DISAGREEMENT_MODEL_ONLY,
+	entity.PolicyDisagreementPolicyOnly: browser_task_v1.PolicyDisagreement_POLICY_DISAGREEMENT_POLICY_ONLY,
+}
+
+var approvalKinds = map[entity.ApprovalKind]browser_task_v1.ApprovalKind{
+	entity.ApprovalKindAction:     browser_task_v1.ApprovalKind_APPROVAL_KIND_ACTION,
+	entity.ApprovalKindUserAction: browser_task_v1.ApprovalKind_APPROVAL_KIND_USER_ACTION,
+}
+
+var approvalStatuses = map[entity.ApprovalStatus]browser_task_v1.ApprovalStatus{
+	entity.ApprovalStatusPending:   browser_task_v1.ApprovalStatus_APPROVAL_STATUS_PENDING,
+	entity.ApprovalStatusApproved:  browser_task_v1.ApprovalStatus_APPROVAL_STATUS_APPROVED,
+	entity.ApprovalStatusRejected:  browser_task_v1.ApprovalStatus_APPROVAL_STATUS_REJECTED,
+	entity.ApprovalStatusExpired:   browser_task_v1.ApprovalStatus_APPROVAL_STATUS_EXPIRED,
+	entity.ApprovalStatusCancelled: browser_task_v1.ApprovalStatus_APPROVAL_STATUS_CANCELLED,
+}
+
+func approvalToProto(approval entity.Approval) *browser_task_v1.Approval {
+	return &browser_task_v1.Approval{
+		ApprovalId:     approval.ID,
+		TaskId:         approval.TaskID,
+		Kind:           approvalKinds[approval.Kind],
+		Action:         approval.Action,
+		Target:         approval.Target,
+		Reasoning:      approval.Reasoning,
+		PageUrl:        approval.PageURL,
+		Status:         approvalStatuses[approval.Status],
+		Comment:        approval.Comment,
+		Rule:           approval.Rule,
+		ModelRequested: approval.ModelRequested,
+		CreatedAt:      timestampOrNil(approval.CreatedAt),
+		ExpiresAt:      timestampOrNil(approval.ExpiresAt),
+		ResolvedAt:     timestampOrNil(approval.ResolvedAt),
+	}
+}
+
+func profileToProto(profile entity.Profile) *browser_task_v1.Profile {
+	return &browser_task_v1.Profile{
+		Name:      profile.Name,
+		Cookies:   int32(profile.Cookies),
+		Origins:   int32(profile.Origins),
+		UpdatedAt: timestampOrNil(profile.UpdatedAt),
+	}
+}
+
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	browser_task_v1 "github.com/vishenosik/ai-cherry-bro/gen/grpc/v1/browser_task"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ProfileUsecase interface {
+	ListProfiles(ctx context.Context) ([]entity.Profile, error)
+	LoadProfile(ctx context.Context, name string) (entity.Profile, json.RawMessage, error)
+	SaveProfile(ctx context.Context, name string, state json.RawMessage) (entity.Profile, error)
+	DeleteProfile(ctx context.Context, name string) error
+}
+
+func (bsa *BrowserServiceApi) ListProfiles(ctx context.Context, req *browser_task_v1.ListProfilesReq) (*browser_task_v1.ListProfilesResp, error) {
+	profiles, err := bsa.profiles.ListProfiles(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	resp := &browser_task_v1.ListProfilesResp{
+		Profiles: make([]*browser_task_v1.Profile, 0, len(profiles)),
+	}
+	for _, profile := range profiles {
+		resp.Profiles = append(resp.Profiles, profileToProto(profile))
+	}
+	return resp, nil
+}
+
+func (bsa *BrowserServiceApi) ExportProfile(ctx context.Context, req *browser_task_v1.ExportProfileReq) (*browser_task_v1.ExportProfileResp, error) {
+	profile, state, err := bsa.profiles.LoadProfile(ctx, req.Name)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	bsa.log.Info("profile exported", slog.String("profile", profile.Name))
+	return &browser_task_v1.ExportProfileResp{
+		Profile:      profileToProto(profile),
+		StorageState: string(state),
+	}, nil
+}
+
+func (bsa *BrowserServiceApi) ImportProfile(ctx context.Context, req *browser_task_v1.ImportProfileReq) (*browser_task_v1.ImportProfileResp, error) {
+	if err := entity.ValidateProfileName(req.Name); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !json.Valid([]byte(req.StorageState)) {
+		return nil, status.Error(codes.InvalidArgument, "storage state is not valid JSON")
+	}
+
+	profile, err := bsa.profiles.SaveProfile(ctx, req.Name, json.RawMessage(req.StorageState))
+	if errors.Is(err, entity.ErrInvalidStorageState) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	bsa.log.Info("profile imported", slog.String("profile", profile.Name))
+	return &browser_task_v1.ImportProfileResp{
+		Profile: profileToProto(profile),
+	}, nil
+}
+
+func (bsa *BrowserServiceApi) WipeProfile(ctx context.Context, req *browser_task_v1.WipeProfileReq) (*browser_task_v1.WipeProfileResp, error) {
+	if err := bsa.profiles.DeleteProfile(ctx, req.Name); err != nil {
+		return nil, grpcError(err)
+	}
+
+	bsa.log.Info("profile wiped", slog.String("profile", req.Name))
+	return &browser_task_v1.WipeProfileResp{}, nil
+}
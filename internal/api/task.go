@@ -2,28 +2,42 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 
+	"github.com/pkg/errors"
 	browser_task_v1 "github.com/vishenosik/ai-cherry-bro/gen/grpc/v1/browser_task"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/jsonschema"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
 	"github.com/vishenosik/gocherry/pkg/logs"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type BrowserTaskUsecase interface {
-	NewTask(ctx context.Context, text string) (task_id string, err error)
+	NewTask(ctx context.Context, text string, opts entity.TaskOptions) (task_id string, err error)
+	GetTask(ctx context.Context, task_id string) (entity.Task, error)
+	WatchTask(ctx context.Context, task_id string, fn func(entity.TaskEvent) error) error
+	CancelTask(ctx context.Context, task_id string) (entity.Task, error)
 }
 
 type BrowserServiceApi struct {
 	browser_task_v1.UnimplementedBrowserTaskServiceServer
-	svc BrowserTaskUsecase
+	svc       BrowserTaskUsecase
+	approvals ApprovalUsecase
+	profiles  ProfileUsecase
 	// log is a structured logger for the application.
 	log *slog.Logger
 }
 
-func NewBrowserServiceApi(svc BrowserTaskUsecase) *BrowserServiceApi {
+func NewBrowserServiceApi(svc BrowserTaskUsecase, approvals ApprovalUsecase, profiles ProfileUsecase) *BrowserServiceApi {
 	return &BrowserServiceApi{
-		svc: svc,
-		log: logs.SetupLogger().With(logs.AppComponent("browser_task_api")),
+		svc:       svc,
+		approvals: approvals,
+		profiles:  profiles,
+		log:       logs.SetupLogger().With(logs.AppComponent("browser_task_api")),
 	}
 }
 
@@ -32,7 +46,57 @@ func (bsa *BrowserServiceApi) RegisterService(server *grpc.Server) {
 }
 
 func (bsa *BrowserServiceApi) NewTask(ctx context.Context, req *browser_task_v1.NewTaskReq) (*browser_task_v1.NewTaskResp, error) {
-	task_id, err := bsa.svc.NewTask(ctx, req.TaskText)
+	snapshotMode, ok := snapshotModeFromProto(req.SnapshotMode)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown snapshot mode: %v", req.SnapshotMode)
+	}
+
+	var answerSchema json.RawMessage
+	if req.AnswerSchema != "" {
+		if _, err := jsonschema.Parse([]byte(req.AnswerSchema)); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		answerSchema = json.RawMessage(req.AnswerSchema)
+	}
+
+	if req.Policy != "" {
+		if _, err := security.ParsePolicy([]byte(req.Policy)); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	domains := entity.DomainRules{
+		Allowed: req.AllowedDomains,
+		Blocked: req.BlockedDomains,
+	}
+	if err := domains.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.Profile != "" {
+		if err := entity.ValidateProfileName(req.Profile); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	browserOptions, ok := browserOptionsFromProto(req.Browser)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown browser engine: %v", req.Browser.Engine)
+	}
+	if err := browserOptions.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	task_id, err := bsa.svc.NewTask(ctx, req.TaskText, entity.TaskOptions{
+		Model:        req.Model,
+		SnapshotMode: snapshotMode,
+		Vision:       req.Vision,
+		AnswerSchema: answerSchema,
+		Policy:       req.Policy,
+		Domains:      domains,
+		Profile:      req.Profile,
+		Browser:      browserOptions,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -40,3 +104,48 @@ func (bsa *BrowserServiceApi) NewTask(ctx context.Context, req *browser_task_v1.
 		TaskId: task_id,
 	}, nil
 }
+
+func (bsa *BrowserServiceApi) GetTask(ctx context.Context, req *browser_task_v1.GetTaskReq) (*browser_task_v1.GetTaskResp, error) {
+	task, err := bsa.svc.GetTask(ctx, req.TaskId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &browser_task_v1.GetTaskResp{
+		Task: taskToProto(task),
+	}, nil
+}
+
+func (bsa *BrowserServiceApi) WatchTask(req *browser_task_v1.WatchTaskReq, stream browser_task_v1.BrowserTaskService_WatchTaskServer) error {
+	err := bsa.svc.WatchTask(stream.Context(), req.TaskId, func(event entity.TaskEvent) error {
+		return stream.Send(taskEventToProto(event))
+	})
+	if err != nil {
+		return grpcError(err)
+	}
+	return nil
+}
+
+func (bsa *BrowserServiceApi) CancelTask(ctx context.Context, req *browser_task_v1.CancelTaskReq) (*browser_task_v1.CancelTaskResp, error) {
+	task, err := bsa.svc.CancelTask(ctx, req.TaskId)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &browser_task_v1.CancelTaskResp{
+		Task: taskToProto(task),
+	}, nil
+}
+
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, entity.ErrTaskNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrApprovalNotFound), errors.Is(err, entity.ErrProfileNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, entity.ErrTaskFinished):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return err
+	}
+}
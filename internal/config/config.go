@@ -0,0 +1,202 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/ai"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/core"
+	_context "github.com/vishenosik/ai-cherry-bro/internal/context"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+	"github.com/vishenosik/ai-cherry-bro/internal/store/local"
+)
+
+// redacted замена значений секретов при выводе
+const redacted = "******"
+
+// DefaultFile файл настроек, который читается, если файл не указан явно
+const DefaultFile = ".env"
+
+// FileFlag флаг с путём к файлу настроек, его регистрирует gocherry.ConfigFlags
+const FileFlag = "config"
+
+// FileFromFlags путь к файлу настроек из разобранного набора флагов
+func FileFromFlags(flags *flag.FlagSet) string {
+	if f := flags.Lookup(FileFlag); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// Config настройки приложения по разделам
+type Config struct {
+	Store    local.Config
+	AI       ai.Config
+	Agent    core.Config
+	Context  _context.Config
+	Browser  browser.Config
+	Security security.Config
+}
+
+// Load читает настройки из файла path (.env, yaml, json или toml) и переменных окружения.
+// Явно указанный файл должен читаться. Без path читается DefaultFile, а если его нет -
+// настройки берутся только из окружения.
+func Load(path string) (Config, error) {
+	var (
+		conf Config
+		err  error
+	)
+
+	if path == "" {
+		path = DefaultFile
+		if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) {
+			path = ""
+		}
+	}
+
+	if path != "" {
+		err = cleanenv.ReadConfig(path, &conf)
+	} else {
+		err = cleanenv.ReadEnv(&conf)
+	}
+	if err != nil {
+		return conf, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	return conf, conf.Validate()
+}
+
+// Validate проверяет все разделы и возвращает все найденные ошибки
+func (c Config) Validate() error {
+	var errs []error
+	for _, section := range c.sections() {
+		if err := section.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", section.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type section struct {
+	name     string
+	value    any
+	validate func() error
+}
+
+func (c Config) sections() []section {
+	return []section{
+		{"store", c.Store, c.Store.Validate},
+		{"ai", c.AI, c.AI.Validate},
+		{"agent", c.Agent, c.Agent.Validate},
+		{"context", c.Context, c.Context.Validate},
+		{"browser", c.Browser, c.Browser.Validate},
+		{"security", c.Security, c.Security.Validate},
+	}
+}
+
+// Redacted копия настроек со скрытыми ключами, паролями и значениями заголовков
+func (c Config) Redacted() Config {
+	mask := func(value string) string {
+		if value == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	c.AI.ApiKey = mask(c.AI.ApiKey)
+	c.AI.OpenAiApiKey = mask(c.AI.OpenAiApiKey)
+	c.Security.VaultKey = mask(c.Security.VaultKey)
+
+	if u, err := url.Parse(c.Browser.Proxy); err == nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		c.Browser.Proxy = u.String()
+	}
+
+	// Заголовки часто несут токены
+	if len(c.Browser.Headers) > 0 {
+		headers := make(map[string]string, len(c.Browser.Headers))
+		for name, value := range c.Browser.Headers {
+			headers[name] = mask(value)
+		}
+		c.Browser.Headers = headers
+	}
+
+	return c
+}
+
+// Print выводит настройки в формате .env со скрытыми секретами
+func (c Config) Print(w io.Writer) error {
+	var sb strings.Builder
+	for i, section := range c.Redacted().sections() {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("# " + section.name + "\n")
+		writeEnv(&sb, reflect.ValueOf(section.value))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeEnv пишет поля с тегом env, вложенные структуры без тега обходит рекурсивно
+func writeEnv(sb *strings.Builder, value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("env")
+		if !ok {
+			if value.Field(i).Kind() == reflect.Struct {
+				writeEnv(sb, value.Field(i))
+			}
+			continue
+		}
+
+		separator := field.Tag.Get("env-separator")
+		if separator == "" {
+			separator = ","
+		}
+		sb.WriteString(fmt.Sprintf("%s=%s\n", name, formatValue(value.Field(i), separator)))
+	}
+}
+
+func formatValue(value reflect.Value, separator string) string {
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+
+	switch value.Kind() {
+	case reflect.Slice:
+		items := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			items = append(items, fmt.Sprint(value.Index(i).Interface()))
+		}
+		return strings.Join(items, separator)
+
+	case reflect.Map:
+		items := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			items = append(items, fmt.Sprintf("%v:%v", key.Interface(), value.MapIndex(key).Interface()))
+		}
+		// Порядок ключей map случаен
+		slices.Sort(items)
+		return strings.Join(items, separator)
+	}
+
+	return fmt.Sprint(value.Interface())
+}
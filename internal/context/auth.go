@@ -0,0 +1,70 @@
+package context
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// loginPath адреса страниц входа
+var loginPath = regexp.MustCompile(`(?i)/(login|log-in|signin|sign-in|sign_in|auth|sso|вход)(/|\.|$)`)
+
+// DetectAuth определяет состояние авторизации по признакам страницы.
+// ok = false, если признаков нет и прежнее состояние домена стоит сохранить.
+func DetectAuth(pageURL string, signals entity.AuthSignals) (state entity.AuthState, ok bool) {
+	state.Domain = Domain(pageURL)
+
+	switch {
+	case signals.Status == 401 || signals.Status == 403:
+		state.AuthRequired = true
+		state.Reason = fmt.Sprintf("HTTP %d", signals.Status)
+
+	case len(signals.Logout) > 0:
+		state.IsLoggedIn = true
+		state.Username = signals.Username
+		state.Reason = fmt.Sprintf("logout control %q", signals.Logout[0])
+
+	case len(signals.Account) > 0:
+		state.IsLoggedIn = true
+		state.Username = signals.Username
+		state.Reason = fmt.Sprintf("account menu %q", signals.Account[0])
+
+	case signals.Redirected && isLoginPage(pageURL):
+		state.AuthRequired = true
+		state.Reason = "redirected to login page"
+
+	case signals.PasswordFields > 0:
+		state.AuthRequired = true
+		state.Reason = "login form with password field"
+
+	case len(signals.Login) > 0:
+		state.Reason = fmt.Sprintf("login control %q", signals.Login[0])
+
+	case isLoginPage(pageURL):
+		state.Reason = "login page"
+
+	default:
+		return state, false
+	}
+
+	return state, true
+}
+
+func isLoginPage(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	return loginPath.MatchString(u.Path)
+}
+
+// Domain домен страницы, состояние авторизации хранится по нему
+func Domain(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	return u.Hostname()
+}
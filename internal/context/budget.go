@@ -0,0 +1,227 @@
+package context
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// PromptBuilder собирает промпт из состояния страницы и истории
+type PromptBuilder func(pageState, history string) []entity.AiMessage
+
+const (
+	// historyShare доля бюджета (за вычетом постоянной части промпта), отводимая истории
+	historyShare = 0.3
+	// minRecentHistory последние шаги, которые никогда не сворачиваются в сводку
+	minRecentHistory = 3
+)
+
+// sectionPriority порядок, в котором секции состояния страницы получают бюджет.
+// Поля форм и кнопки нужны для действий, ссылки обычно самые многочисленные.
+var sectionPriority = []string{
+	"FORM ELEMENTS",
+	"BUTTONS",
+	"HEADINGS",
+	"LINKS",
+}
+
+// FitPrompt собирает промпт в пределах maxTokens:
+// история сжимается в сводку, если превышает свою долю,
+// а секции состояния страницы урезаются по приоритету
+func (m *Manager) FitPrompt(build PromptBuilder, pageState string) []entity.AiMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Постоянная часть: системный промпт, задача и шаблон
+	fixed := CountMessages(m.tokens, build("", ""))
+	available := m.maxTokens - fixed
+
+	m.compressHistory(int(float64(available) * historyShare))
+	history := m.history()
+
+	pageBudget := available - m.tokens.Count(history)
+	return build(m.fitPageState(pageState, pageBudget), history)
+}
+
+// compressHistory сворачивает старые шаги в сводку, пока история не уложится в budget
+func (m *Manager) compressHistory(budget int) {
+	for len(m.steps) > minRecentHistory && m.tokens.Count(m.history()) > budget {
+		m.fold(1)
+	}
+
+	// Крайний случай: даже сводка не помещается, отбрасываем её начало
+	for len(m.summary) > 1 && m.tokens.Count(m.history()) > budget {
+		m.summary = m.summary[1:]
+		m.summaryTrimmed = true
+	}
+}
+
+// fold переносит n самых старых шагов в сводку
+func (m *Manager) fold(n int) {
+	n = min(n, len(m.steps))
+	for _, step := range m.steps[:n] {
+		m.summary = appendSummary(m.summary, summarizeStep(step))
+	}
+	m.folded += n
+	m.steps = slices.Delete(m.steps, 0, n)
+}
+
+// summarizeStep оставляет от шага действие и цель без рассуждений
+func summarizeStep(step string) string {
+	if i := strings.Index(step, " -> "); i >= 0 {
+		step = step[:i]
+	}
+	step = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(step), ":"))
+	return strings.Replace(step, ": ", " ", 1)
+}
+
+// appendSummary объединяет одинаковые подряд идущие шаги: "scroll x3"
+func appendSummary(summary []summaryItem, step string) []summaryItem {
+	if len(summary) > 0 && summary[len(summary)-1].step == step {
+		summary[len(summary)-1].count++
+		return summary
+	}
+	return append(summary, summaryItem{step: step, count: 1})
+}
+
+type summaryItem struct {
+	step  string
+	count int
+}
+
+func (s summaryItem) String() string {
+	if s.count > 1 {
+		return fmt.Sprintf("%s x%d", s.step, s.count)
+	}
+	return s.step
+}
+
+// history отдаёт сводку и последние шаги, вызывается под m.mu
+func (m *Manager) history() string {
+	if m.folded == 0 && len(m.steps) == 0 {
+		return "No recent actions"
+	}
+
+	var sb strings.Builder
+	if m.folded > 0 {
+		items := make([]string, 0, len(m.summary)+1)
+		if m.summaryTrimmed {
+			items = append(items, "...")
+		}
+		for _, item := range m.summary {
+			items = append(items, item.String())
+		}
+		sb.WriteString(fmt.Sprintf("Summary of %d earlier steps: %s\n", m.folded, strings.Join(items, "; ")))
+	}
+	sb.WriteString(strings.Join(m.steps, "\n"))
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+type pageSection struct {
+	header string
+	lines  []string
+}
+
+// fitPageState урезает состояние страницы до budget токенов.
+// Вступление (URL, заголовок) сохраняется целиком, секции заполняются
+// в порядке sectionPriority, вместо отброшенных строк пишется их количество.
+func (m *Manager) fitPageState(pageState string, budget int) string {
+	if m.tokens.Count(pageState) <= budget {
+		return pageState
+	}
+
+	intro, sections := splitSections(pageState)
+	budget -= m.tokens.Count(intro)
+
+	// Заголовки секций и пометки о сокращении тоже занимают место
+	kept := make([]int, len(sections))
+	for _, i := range sectionOrder(sections) {
+		budget -= m.tokens.Count(sections[i].header) + m.tokens.Count(omittedNote(len(sections[i].lines)))
+
+		for _, line := range sections[i].lines {
+			cost := m.tokens.Count(line) + 1
+			if cost > budget {
+				break
+			}
+			budget -= cost
+			kept[i]++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(intro)
+	for i, section := range sections {
+		sb.WriteString(section.header)
+		for _, line := range section.lines[:kept[i]] {
+			sb.WriteString(line + "\n")
+		}
+		if omitted := len(section.lines) - kept[i]; omitted > 0 {
+			sb.WriteString(omittedNote(omitted) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+func omittedNote(n int) string {
+	return fmt.Sprintf("... %d more omitted", n)
+}
+
+// splitSections делит состояние страницы по строкам вида "--- NAME ---"
+func splitSections(pageState string) (string, []pageSection) {
+	var (
+		intro    strings.Builder
+		sections []pageSection
+	)
+
+	for _, line := range strings.SplitAfter(pageState, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "--- ") && strings.HasSuffix(trimmed, " ---"):
+			sections = append(sections, pageSection{header: line})
+		case len(sections) == 0:
+			intro.WriteString(line)
+		case trimmed == "":
+			// Пустые строки между секциями относятся к следующему заголовку
+			sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, "")
+		default:
+			sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	// Хвостовые пустые строки секции переносим в заголовок следующей
+	for i := range sections {
+		lines := sections[i].lines
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+			if i+1 < len(sections) {
+				sections[i+1].header = "\n" + sections[i+1].header
+			}
+		}
+		sections[i].lines = lines
+	}
+
+	return intro.String(), sections
+}
+
+// sectionOrder индексы секций по убыванию приоритета, неизвестные секции - в конце
+func sectionOrder(sections []pageSection) []int {
+	rank := func(s pageSection) int {
+		name := strings.Trim(strings.TrimSpace(s.header), "- ")
+		if i := slices.Index(sectionPriority, name); i >= 0 {
+			return i
+		}
+		return len(sectionPriority)
+	}
+
+	order := make([]int, len(sections))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return rank(sections[a]) - rank(sections[b])
+	})
+	return order
+}
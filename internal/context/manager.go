@@ -2,42 +2,95 @@
 package context
 
 import (
+	"fmt"
 	"strings"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
 )
 
-type AuthState struct {
-	IsLoggedIn   bool
-	Username     string
-	Domain       string
-	AuthRequired bool
+type Config struct {
+	// MaxTokens бюджет промпта, см. FitPrompt
+	MaxTokens int `env:"CONTEXT_MAX_TOKENS" env-default:"8000"`
+	// MaxHistory шагов истории дословно, более старые сворачиваются в сводку
+	MaxHistory int `env:"CONTEXT_MAX_HISTORY" env-default:"15"`
+}
+
+func (conf Config) Validate() error {
+	if conf.MaxTokens < 1000 {
+		return fmt.Errorf("context max tokens %d is too small, at least 1000 is needed", conf.MaxTokens)
+	}
+	if conf.MaxHistory < minRecentHistory {
+		return fmt.Errorf("context max history must be at least %d", minRecentHistory)
+	}
+	return nil
 }
 
 type Manager struct {
-	maxTokens  int
-	history    []string
+	mu sync.Mutex
+
+	// maxTokens бюджет промпта, см. FitPrompt
+	maxTokens int
+	tokens    TokenCounter
+
+	// steps последние шаги дословно, более старые свёрнуты в summary
+	steps      []string
 	maxHistory int
-	authStates map[string]*AuthState // domain -> auth state
+	summary    []summaryItem
+	// folded сколько шагов свёрнуто в summary
+	folded         int
+	summaryTrimmed bool
+
+	authStates map[string]entity.AuthState // domain -> auth state
 }
 
-func NewManager(maxTokens int) *Manager {
+func NewManager(conf Config, tokens TokenCounter) *Manager {
 	return &Manager{
-		maxTokens:  maxTokens,
-		maxHistory: 15,
-		authStates: make(map[string]*AuthState),
+		maxTokens:  conf.MaxTokens,
+		tokens:     tokens,
+		maxHistory: conf.MaxHistory,
+		authStates: make(map[string]entity.AuthState),
 	}
 }
 
-func (m *Manager) UpdateAuthState(url string, isLoggedIn bool, username string) {
-	domain := extractDomain(url)
-	m.authStates[domain] = &AuthState{
-		IsLoggedIn:   isLoggedIn,
-		Username:     username,
-		Domain:       domain,
-		AuthRequired: false,
+// UpdateAuthState обновляет состояние домена по признакам страницы.
+// Страница без признаков состояние не меняет. Возвращает новое состояние
+// и true, если оно изменилось.
+func (m *Manager) UpdateAuthState(url string, signals entity.AuthSignals) (entity.AuthState, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, known := m.authStates[Domain(url)]
+
+	state, ok := DetectAuth(url, signals)
+	if !ok || state.Domain == "" {
+		return prev, false
+	}
+	// Имя пользователя видно не на каждой странице
+	if state.IsLoggedIn && state.Username == "" && prev.IsLoggedIn {
+		state.Username = prev.Username
 	}
+
+	m.authStates[state.Domain] = state
+	changed := !known || prev.IsLoggedIn != state.IsLoggedIn ||
+		prev.AuthRequired != state.AuthRequired || prev.Username != state.Username
+	return state, changed
 }
 
+// CheckAuthRequired нужен ли задаче вход на текущем домене: по состоянию,
+// определённому на страницах домена, а пока его нет - по тексту задачи
 func (m *Manager) CheckAuthRequired(task string, currentURL string) bool {
+	m.mu.Lock()
+	state := m.authStates[Domain(currentURL)]
+	m.mu.Unlock()
+
+	switch {
+	case state.IsLoggedIn:
+		return false
+	case state.AuthRequired:
+		return true
+	}
+
 	// Анализируем задачу на необходимость авторизации
 	authKeywords := []string{
 		"мой", "мои", "моё", "my", "личн", "профиль", "profile",
@@ -54,41 +107,42 @@ func (m *Manager) CheckAuthRequired(task string, currentURL string) bool {
 	return false
 }
 
-func (m *Manager) GetAuthState(domain string) *AuthState {
-	if state, exists := m.authStates[domain]; exists {
-		return state
-	}
-	return &AuthState{
-		IsLoggedIn:   false,
-		AuthRequired: false,
-	}
-}
+// GetAuthState состояние домена, пустой Reason - состояние ещё не определено
+func (m *Manager) GetAuthState(domain string) *entity.AuthState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-func extractDomain(url string) string {
-	// Упрощенное извлечение домена
-	if strings.Contains(url, "://") {
-		parts := strings.Split(url, "/")
-		if len(parts) >= 3 {
-			return parts[2]
-		}
+	state, exists := m.authStates[domain]
+	if !exists {
+		state.Domain = domain
 	}
-	return url
+	return &state
 }
 
 func (m *Manager) AddToHistory(action string) {
-	m.history = append(m.history, action)
-	if len(m.history) > m.maxHistory {
-		m.history = m.history[1:]
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.steps = append(m.steps, action)
+	// Старые шаги не теряются, а попадают в сводку
+	if len(m.steps) > m.maxHistory {
+		m.fold(len(m.steps) - m.maxHistory)
 	}
 }
 
 func (m *Manager) GetHistory() string {
-	if len(m.history) == 0 {
-		return "No recent actions"
-	}
-	return strings.Join(m.history, "\n")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.history()
 }
 
 func (m *Manager) ClearHistory() {
-	m.history = nil
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.steps = nil
+	m.summary = nil
+	m.folded = 0
+	m.summaryTrimmed = false
 }
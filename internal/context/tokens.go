@@ -0,0 +1,162 @@
+package context
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// TokenCounter оценивает число токенов в тексте
+type TokenCounter interface {
+	Count(text string) int
+}
+
+const (
+	// messageOverhead служебные токены на каждое сообщение чата (роль, разделители)
+	messageOverhead = 4
+	// imageTokens оценка стоимости скриншота viewport у мультимодальных моделей
+	imageTokens = 1000
+)
+
+// CountMessages оценивает размер промпта целиком
+func CountMessages(counter TokenCounter, messages []entity.AiMessage) int {
+	total := 0
+	for _, msg := range messages {
+		total += messageOverhead + counter.Count(msg.Role) + counter.Count(msg.Content)
+		total += imageTokens * len(msg.Images)
+	}
+	return total
+}
+
+// estimator приближает BPE токенизаторы без словаря: текст режется на
+// последовательности одного класса символов, как это делает pre-tokenizer,
+// и для каждой берётся среднее число символов на токен у данного словаря.
+// Оценка намеренно округляется вверх, чтобы промпт не превысил бюджет.
+type estimator struct {
+	// latin символов латиницы на токен
+	latin float64
+	// other символов прочих алфавитов (кириллица и т.п.) на токен
+	other float64
+	// digits цифр на токен
+	digits float64
+}
+
+var (
+	// o200k_base: gpt-4o, gpt-4.1, o1, o3
+	o200k = estimator{latin: 6, other: 3.5, digits: 3}
+	// cl100k_base: gpt-4, gpt-3.5
+	cl100k = estimator{latin: 5, other: 2.5, digits: 3}
+	// словари Claude, Llama 3, DeepSeek близки по плотности
+	claude   = estimator{latin: 4.5, other: 2.5, digits: 3}
+	llama    = estimator{latin: 5.5, other: 3, digits: 3}
+	deepseek = estimator{latin: 5, other: 3, digits: 3}
+	// fallback для неизвестных моделей
+	generic = estimator{latin: 4, other: 2, digits: 2}
+)
+
+// NewTokenCounter подбирает оценку под токенизатор модели
+func NewTokenCounter(model string) TokenCounter {
+	model = strings.ToLower(model)
+
+	switch {
+	case strings.HasPrefix(model, "gpt-4o"),
+		strings.HasPrefix(model, "gpt-4.1"),
+		strings.HasPrefix(model, "gpt-5"),
+		strings.HasPrefix(model, "o1"),
+		strings.HasPrefix(model, "o3"),
+		strings.HasPrefix(model, "o4"):
+		return o200k
+	case strings.HasPrefix(model, "gpt-"):
+		return cl100k
+	case strings.Contains(model, "claude"):
+		return claude
+	case strings.Contains(model, "llama"):
+		return llama
+	case strings.Contains(model, "deepseek"):
+		return deepseek
+	default:
+		return generic
+	}
+}
+
+type runeClass int
+
+const (
+	classSpace runeClass = iota
+	classNewline
+	classLatin
+	classOther
+	classDigit
+	classIdeograph
+	classPunct
+)
+
+func classify(r rune) runeClass {
+	switch {
+	case r == '\n':
+		return classNewline
+	case unicode.IsSpace(r):
+		return classSpace
+	case r < unicode.MaxASCII && unicode.IsLetter(r):
+		return classLatin
+	case unicode.IsDigit(r):
+		return classDigit
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+		return classIdeograph
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		return classOther
+	default:
+		return classPunct
+	}
+}
+
+func (e estimator) Count(text string) int {
+	total := 0.0
+
+	runLen := 0
+	runClass := classSpace
+
+	flush := func() {
+		if runLen == 0 {
+			return
+		}
+		switch runClass {
+		case classLatin:
+			total += ceil(float64(runLen) / e.latin)
+		case classOther:
+			total += ceil(float64(runLen) / e.other)
+		case classDigit:
+			total += ceil(float64(runLen) / e.digits)
+		case classIdeograph:
+			total += float64(runLen)
+		case classPunct:
+			// Повторы вроде "---" или "===" сливаются в один-два токена
+			total += ceil(float64(runLen) / 2)
+		case classNewline:
+			total++
+		}
+		// Одиночные пробелы входят в токен следующего слова
+		runLen = 0
+	}
+
+	for _, r := range text {
+		class := classify(r)
+		if class != runClass {
+			flush()
+			runClass = class
+		}
+		runLen++
+	}
+	flush()
+
+	return int(total)
+}
+
+func ceil(v float64) float64 {
+	n := float64(int(v))
+	if v > n {
+		n++
+	}
+	return n
+}
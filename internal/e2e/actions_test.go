@@ -0,0 +1,174 @@
+package e2e
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+)
+
+func TestPagerActions(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/actions.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	// Состояние select и флажков видно модели
+	assertState(t, ctx, page,
+		`select "Size" selected "Small", options: "Small", "Medium", "Large"`,
+		`input[checkbox] "Gift wrap" (unchecked)`,
+		`input[file] "Resume"`,
+	)
+
+	t.Run("select", func(t *testing.T) {
+		size := elementID(t, ctx, page, `select "Size"`)
+		if err := page.SelectOption(ctx, size, "", "Medium"); err != nil {
+			t.Fatalf("select by label: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Selected M", `selected "Medium"`)
+
+		if err := page.SelectOption(ctx, size, "", "L"); err != nil {
+			t.Fatalf("select by value: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Selected L")
+
+		err := page.SelectOption(ctx, size, "", "Huge")
+		if err == nil || !strings.Contains(err.Error(), `available: "Small", "Medium", "Large"`) {
+			t.Errorf("error = %v, want available options", err)
+		}
+
+		err = page.SelectOption(ctx, 0, "Menu", "Medium")
+		if err == nil || !strings.Contains(err.Error(), "not a dropdown") {
+			t.Errorf("error = %v, want not a dropdown", err)
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		if err := page.Hover(ctx, 0, "Menu"); err != nil {
+			t.Fatalf("hover: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Hovered menu")
+	})
+
+	t.Run("check", func(t *testing.T) {
+		gift := elementID(t, ctx, page, `input[checkbox] "Gift wrap"`)
+		if err := page.SetChecked(ctx, gift, "", true); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Gift wrap on", `"Gift wrap" (checked)`)
+
+		if err := page.SetChecked(ctx, gift, "", false); err != nil {
+			t.Fatalf("uncheck: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Gift wrap off", `"Gift wrap" (unchecked)`)
+	})
+
+	t.Run("press key", func(t *testing.T) {
+		search := elementID(t, ctx, page, `input[text] "search products"`)
+		if err := page.TypeText(ctx, search, "", "shoes"); err != nil {
+			t.Fatalf("type: %v", err)
+		}
+		if err := page.PressKey(ctx, search, "", "Enter"); err != nil {
+			t.Fatalf("press Enter: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Searched shoes")
+
+		// Без элемента клавиша уходит странице
+		if err := page.PressKey(ctx, 0, "", "Escape"); err != nil {
+			t.Fatalf("press Escape: %v", err)
+		}
+		assertState(t, ctx, page, "H2: Pressed Escape")
+	})
+
+	t.Run("uploads disabled", func(t *testing.T) {
+		resume := elementID(t, ctx, page, `input[file] "Resume"`)
+		err := page.UploadFile(ctx, resume, "", "resume.txt")
+		if err == nil || !strings.Contains(err.Error(), "uploads are disabled") {
+			t.Errorf("error = %v, want uploads are disabled", err)
+		}
+	})
+}
+
+func TestPagerUploadFile(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "uploads")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(dir, "resume.txt"):  "resume",
+		filepath.Join(root, "secret.txt"): "secret",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(root, "secret.txt"), filepath.Join(dir, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	site := startSite(t)
+	agent := newBrowserAgent(t, browser.Config{Headless: true, UploadDir: dir}, nil, nil)
+	page := newPage(t, agent)
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/actions.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+	resume := elementID(t, ctx, page, `input[file] "Resume"`)
+
+	if err := page.UploadFile(ctx, resume, "", "resume.txt"); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	assertState(t, ctx, page, "H2: Uploaded resume.txt")
+
+	// Имя файла приходит от модели, за пределы каталога загрузок не выходим
+	for file, want := range map[string]string{
+		"../secret.txt":                   "outside the uploads directory",
+		filepath.Join(root, "secret.txt"): "outside the uploads directory",
+		"link.txt":                        "outside the uploads directory",
+		"missing.txt":                     "not found",
+		".":                               "not a file",
+	} {
+		err := page.UploadFile(ctx, resume, "", file)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("upload %q: error = %v, want %s", file, err, want)
+		}
+	}
+}
+
+func TestPagerGoBack(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	err := page.GoBack(ctx)
+	if err == nil || !strings.Contains(err.Error(), "no previous page") {
+		t.Errorf("error = %v, want no previous page", err)
+	}
+
+	for _, path := range []string{"/actions.html", "/strategies.html"} {
+		if err := page.Navigate(ctx, site.URL+path); err != nil {
+			t.Fatalf("navigate: %v", err)
+		}
+	}
+
+	if err := page.GoBack(ctx); err != nil {
+		t.Fatalf("go back: %v", err)
+	}
+	if got := page.CurrentURL(); got != site.URL+"/actions.html" {
+		t.Errorf("current url = %q, want %q", got, site.URL+"/actions.html")
+	}
+}
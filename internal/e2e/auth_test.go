@@ -0,0 +1,65 @@
+package e2e
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+func TestPagerAuthSignals(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	signals := func(path string) entity.AuthSignals {
+		t.Helper()
+		if path != "" {
+			if err := page.Navigate(ctx, site.URL+path); err != nil {
+				t.Fatalf("navigate %s: %v", path, err)
+			}
+		}
+		signals, err := page.AuthSignals(ctx)
+		if err != nil {
+			t.Fatalf("auth signals: %v", err)
+		}
+		return signals
+	}
+
+	if got := signals("/admin"); got.Status != 401 {
+		t.Errorf("/admin: %+v", got)
+	}
+
+	got := signals("/account")
+	if !got.Redirected || got.Status != 200 || got.PasswordFields != 1 ||
+		len(got.Login) == 0 || got.Login[0] != "Sign in" || len(got.Logout) > 0 {
+		t.Errorf("/account: %+v", got)
+	}
+
+	for _, field := range []struct{ name, text string }{{"username", "alice"}, {"password", "secret"}} {
+		if err := page.TypeText(ctx, 0, field.name, field.text); err != nil {
+			t.Fatalf("type %s: %v", field.name, err)
+		}
+	}
+	if err := page.ClickElement(ctx, 0, "Sign in"); err != nil {
+		t.Fatalf("sign in: %v", err)
+	}
+
+	got = signals("")
+	if len(got.Logout) == 0 || got.Logout[0] != "Log out" || got.Username != "alice" || got.PasswordFields != 0 {
+		t.Errorf("after login: %+v", got)
+	}
+
+	// Русские подписи: регулярные выражения выполняются в браузере
+	got = signals("/ru-login.html")
+	if !slices.Equal(got.Login, []string{"Войти в аккаунт"}) || len(got.Logout) > 0 {
+		t.Errorf("/ru-login.html: %+v", got)
+	}
+	got = signals("/ru-account.html")
+	if len(got.Login) > 0 || !slices.Equal(got.Logout, []string{"Выйти"}) || got.Username != "Мария" {
+		t.Errorf("/ru-account.html: %+v", got)
+	}
+}
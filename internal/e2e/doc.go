@@ -0,0 +1,6 @@
+// Package e2e содержит сквозные тесты агента: headless браузер,
+// локальные сайты из testdata/sites и сценарный AI клиент.
+//
+// Для запуска нужны установленные драйвер и браузеры playwright,
+// без них тесты пропускаются.
+package e2e
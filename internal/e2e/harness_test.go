@@ -0,0 +1,244 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/core"
+	_context "github.com/vishenosik/ai-cherry-bro/internal/context"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// startSite раздает фикстуры из testdata/sites
+func startSite(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("testdata/sites")))
+	// Закрытые страницы: без сессии сайт отправляет на вход или отвечает 401
+	mux.Handle("/account", http.RedirectHandler("/login.html", http.StatusFound))
+	mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	})
+	// Заголовки запроса, которые отправил браузер
+	mux.HandleFunc("/headers", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Headers</title></head><body>")
+		for _, name := range []string{"X-Agent", "X-Task"} {
+			fmt.Fprintf(w, "<h2>%s: %s</h2>", name, html.EscapeString(r.Header.Get(name)))
+		}
+		fmt.Fprint(w, "</body></html>")
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newAgent запускает headless браузер или пропускает тест, если playwright не установлен
+func newAgent(t *testing.T) *browser.BrowserAgent {
+	t.Helper()
+	return newSecretsAgent(t, nil)
+}
+
+// newSecretsAgent браузер, подставляющий секреты при вводе текста
+func newSecretsAgent(t *testing.T, secrets browser.Secrets) *browser.BrowserAgent {
+	t.Helper()
+	return newBrowserAgent(t, browser.Config{Headless: true}, secrets, nil)
+}
+
+func newBrowserAgent(t *testing.T, conf browser.Config, secrets browser.Secrets, profiles browser.Profiles) *browser.BrowserAgent {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("e2e tests are skipped in short mode")
+	}
+
+	// Пропускаем только без браузера, ошибки настроек - провал теста.
+	// С E2E_REQUIRED (task test-e2e) браузер обязателен
+	agent, err := browser.NewBrowserAgent(conf, secrets, profiles)
+	if errors.Is(err, browser.ErrUnavailable) && os.Getenv("E2E_REQUIRED") == "" {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("new browser agent: %v", err)
+	}
+	t.Cleanup(func() {
+		agent.Close(context.Background())
+	})
+	return agent
+}
+
+func newPage(t *testing.T, agent *browser.BrowserAgent) core.Page {
+	t.Helper()
+
+	page, err := agent.NewPage(entity.TaskOptions{})
+	if err != nil {
+		t.Fatalf("new page: %v", err)
+	}
+	t.Cleanup(func() {
+		page.Close()
+	})
+	return page
+}
+
+func newContextManager(task entity.PoolTask) core.ContextManager {
+	return _context.NewManager(_context.Config{MaxTokens: 8000, MaxHistory: 15}, _context.NewTokenCounter(task.Options.Model))
+}
+
+// recordingBrowser запоминает открытые оркестратором страницы.
+// Страницы остаются открытыми до конца теста, чтобы проверить их состояние.
+type recordingBrowser struct {
+	core.Browser
+
+	mu    sync.Mutex
+	pages []core.Page
+}
+
+func (rb *recordingBrowser) NewPage(options entity.TaskOptions) (core.Page, error) {
+	page, err := rb.Browser.NewPage(options)
+	if err != nil {
+		return nil, err
+	}
+
+	rb.mu.Lock()
+	rb.pages = append(rb.pages, page)
+	rb.mu.Unlock()
+
+	return keptPage{page}, nil
+}
+
+// keptPage не закрывается оркестратором
+type keptPage struct {
+	core.Page
+}
+
+func (keptPage) Close() error {
+	return nil
+}
+
+// pageByURL находит открытую страницу по фрагменту адреса
+func (rb *recordingBrowser) pageByURL(t *testing.T, fragment string) core.Page {
+	t.Helper()
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	for _, page := range rb.pages {
+		if strings.Contains(page.CurrentURL(), fragment) {
+			return page
+		}
+	}
+	t.Fatalf("no page with url containing %q", fragment)
+	return nil
+}
+
+func (rb *recordingBrowser) close() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	for _, page := range rb.pages {
+		page.Close()
+	}
+}
+
+func (rb *recordingBrowser) lastPage(t *testing.T) core.Page {
+	t.Helper()
+
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if len(rb.pages) == 0 {
+		t.Fatal("orchestrator opened no pages")
+	}
+	return rb.pages[len(rb.pages)-1]
+}
+
+// recorder собирает шаги и итоги задач (core.TaskTracker)
+type recorder struct {
+	mu      sync.Mutex
+	steps   []entity.TaskStep
+	result  *entity.TaskResult
+	results map[string]entity.TaskResult
+}
+
+func (r *recorder) TaskStarted(id string) {}
+
+func (r *recorder) TaskStep(id string, step entity.TaskStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) TaskFinished(id string, result entity.TaskResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result = &result
+
+	if r.results == nil {
+		r.results = make(map[string]entity.TaskResult)
+	}
+	r.results[id] = result
+}
+
+// wait ждёт завершения n задач
+func (r *recorder) wait(t *testing.T, n int, timeout time.Duration) map[string]entity.TaskResult {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		r.mu.Lock()
+		if len(r.results) >= n {
+			results := maps.Clone(r.results)
+			r.mu.Unlock()
+			return results
+		}
+		r.mu.Unlock()
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%d tasks did not finish in %s", n, timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// actions возвращает историю выполненных действий
+func (r *recorder) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	actions := make([]string, 0, len(r.steps))
+	for _, step := range r.steps {
+		actions = append(actions, step.Action.Action)
+	}
+	return actions
+}
+
+// autoApprover одобряет все запросы и запоминает их
+type autoApprover struct {
+	mu        sync.Mutex
+	approvals []entity.Approval
+}
+
+func (aa *autoApprover) Approve(ctx context.Context, approval entity.Approval) (bool, error) {
+	aa.mu.Lock()
+	defer aa.mu.Unlock()
+	aa.approvals = append(aa.approvals, approval)
+	return true, nil
+}
+
+func (aa *autoApprover) count() int {
+	aa.mu.Lock()
+	defer aa.mu.Unlock()
+	return len(aa.approvals)
+}
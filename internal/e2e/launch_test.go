@@ -0,0 +1,57 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+func TestPagerBrowserOptions(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	ctx := context.Background()
+
+	agent := newBrowserAgent(t, browser.Config{
+		Headless: true,
+		Viewport: "800x600",
+		Locale:   "de-DE",
+		Timezone: "Europe/Berlin",
+		Headers:  map[string]string{"X-Agent": "agent"},
+	}, nil, nil)
+
+	open := func(options entity.BrowserOptions, path string, want ...string) {
+		t.Helper()
+
+		page, err := agent.NewPage(entity.TaskOptions{Browser: options})
+		if err != nil {
+			t.Fatalf("new page: %v", err)
+		}
+		defer page.Close()
+
+		if err := page.Navigate(ctx, site.URL+path); err != nil {
+			t.Fatalf("navigate: %v", err)
+		}
+		assertState(t, ctx, page, want...)
+	}
+
+	open(entity.BrowserOptions{}, "/browser.html",
+		"H2: Locale: de-DE", "H2: Timezone: Europe/Berlin", "H2: Viewport: 800x600")
+	open(entity.BrowserOptions{}, "/headers", "H2: X-Agent: agent")
+
+	task := entity.BrowserOptions{
+		UserAgent: "cherry-test",
+		Timezone:  "Asia/Tokyo",
+		Viewport:  &entity.Viewport{Width: 390, Height: 844},
+		Headers:   map[string]string{"X-Task": "task"},
+	}
+	open(task, "/browser.html",
+		"H2: User agent: cherry-test", "H2: Locale: de-DE", "H2: Timezone: Asia/Tokyo", "H2: Viewport: 390x844")
+	open(task, "/headers", "H2: X-Agent: agent", "H2: X-Task: task")
+
+	if _, err := agent.NewPage(entity.TaskOptions{Browser: entity.BrowserOptions{Device: "Nokia 3310"}}); err == nil {
+		t.Error("unknown device accepted")
+	}
+}
@@ -0,0 +1,733 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/ai"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/core"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+)
+
+type scenario struct {
+	task    string
+	options entity.TaskOptions
+	// done шаги, выполненные до перезапуска агента
+	done    int
+	steps   []entity.AiResponse
+	page    *recordingBrowser
+	tracker *recorder
+	approve *autoApprover
+	// vault учётные данные задачи, может отсутствовать
+	vault *security.Vault
+	// client сценарный AI клиент, для проверки промптов
+	client *ai.MockClient
+}
+
+// run выполняет задачу оркестратором со сценарным AI клиентом
+func (s *scenario) run(t *testing.T) entity.TaskResult {
+	t.Helper()
+
+	script := ai.MockScript{}
+	for i := range s.steps {
+		script.Steps = append(script.Steps, ai.MockStep{Response: &s.steps[i]})
+	}
+
+	aiClient, err := ai.NewMockClient(script)
+	if err != nil {
+		t.Fatalf("mock client: %v", err)
+	}
+	s.client = aiClient
+
+	var secrets browser.Secrets
+	if s.vault != nil {
+		secrets = s.vault
+	}
+	s.page = &recordingBrowser{Browser: newSecretsAgent(t, secrets)}
+	t.Cleanup(s.page.close)
+	s.tracker = &recorder{}
+	s.approve = &autoApprover{}
+
+	orch, err := core.NewOrchestrator(
+		core.Config{Workers: 1, SnapshotMode: entity.SnapshotModeDOM, MaxSteps: 50, StepDelay: 2 * time.Second},
+		s.page,
+		aiClient,
+		newContextManager,
+		security.NewLayer(nil, s.approve, security.WithVault(s.vault)),
+		s.tracker,
+	)
+	if err != nil {
+		t.Fatalf("orchestrator: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := orch.Start(ctx); err != nil {
+		t.Fatalf("start orchestrator: %v", err)
+	}
+	t.Cleanup(func() {
+		orch.Stop(context.Background())
+	})
+
+	orch.RunTask(entity.PoolTask{
+		ID:      uuid.New().String(),
+		Text:    s.task,
+		Options: s.options,
+		Steps:   s.done,
+		Ctx:     ctx,
+	})
+
+	if s.tracker.result == nil {
+		t.Fatal("task finished without result")
+	}
+	return *s.tracker.result
+}
+
+func assertSucceeded(t *testing.T, result entity.TaskResult) {
+	t.Helper()
+	if result.Status != entity.TaskStatusSucceeded {
+		t.Fatalf("status = %s (%s), want %s", result.Status, result.Error, entity.TaskStatusSucceeded)
+	}
+}
+
+func assertActions(t *testing.T, s *scenario) {
+	t.Helper()
+
+	want := make([]string, 0, len(s.steps))
+	for _, step := range s.steps {
+		want = append(want, step.Action)
+	}
+	if got := s.tracker.actions(); !slices.Equal(got, want) {
+		t.Errorf("actions = %v, want %v", got, want)
+	}
+}
+
+func TestOrchestratorResumedTask(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Open the shop",
+		done: 2,
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/cards.html", Reasoning: "open the shop again"},
+			{Action: "complete", Reasoning: "shop is open"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	// Шаги после перезапуска не перезаписывают сохранённые
+	for i, step := range s.tracker.steps {
+		if step.Number != s.done+i+1 {
+			t.Errorf("step %d number = %d, want %d", i, step.Number, s.done+i+1)
+		}
+	}
+}
+
+func TestOrchestratorLogin(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Log in as alice",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/login.html", Reasoning: "open login page"},
+			{Action: "type", Target: "username", Text: "alice", Reasoning: "enter user name"},
+			{Action: "type", Target: "password", Text: "secret", Reasoning: "enter password"},
+			{Action: "click", Target: "Sign in", Reasoning: "log in"},
+			{Action: "complete", Text: "logged in", Reasoning: "welcome message is shown"},
+		},
+	}
+
+	result := s.run(t)
+	assertSucceeded(t, result)
+	assertActions(t, s)
+	assertState(t, context.Background(), s.page.lastPage(t), "Page Title: Dashboard", "H1: Welcome, alice")
+
+	if result.Answer != "logged in" {
+		t.Errorf("answer = %q, want %q", result.Answer, "logged in")
+	}
+
+	// Перед последним шагом модель знает, что вход выполнен
+	calls := s.client.Calls()
+	for i, want := range map[int]string{
+		1:              "AUTH STATE for 127.0.0.1: not logged in, the page requires login",
+		len(calls) - 1: `AUTH STATE for 127.0.0.1: logged in as "alice"`,
+	} {
+		if prompt := calls[i][len(calls[i])-1].Content; !strings.Contains(prompt, want) {
+			t.Errorf("prompt %d does not contain %q:\n%s", i, want, prompt)
+		}
+	}
+}
+
+func TestOrchestratorLoginAccessibilityMode(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task:    "Log in as alice",
+		options: entity.TaskOptions{SnapshotMode: entity.SnapshotModeAccessibility},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/login.html", Reasoning: "open login page"},
+			{Action: "type", Target: "username", Text: "alice", Reasoning: "enter user name"},
+			{Action: "type", Target: "password", Text: "secret", Reasoning: "enter password"},
+			{Action: "click", Target: "Sign in", Reasoning: "log in"},
+			{Action: "complete", Text: "logged in", Reasoning: "welcome message is shown"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	state, err := s.page.lastPage(t).ExtractPageState(context.Background(), entity.SnapshotModeAccessibility)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+	if !strings.Contains(state, `heading "Welcome, alice" [level=1]`) {
+		t.Errorf("no welcome heading in accessibility tree:\n%s", state)
+	}
+}
+
+func TestOrchestratorVisionMode(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	script := ai.MockScript{Steps: []ai.MockStep{
+		{Response: &entity.AiResponse{Action: "navigate", URL: site.URL + "/cards.html", Reasoning: "open catalog"}},
+		{Response: &entity.AiResponse{Action: "complete", Reasoning: "done"}},
+	}}
+	aiClient, err := ai.NewMockClient(script)
+	if err != nil {
+		t.Fatalf("mock client: %v", err)
+	}
+
+	tracker := &recorder{}
+	orch, err := core.NewOrchestrator(
+		core.Config{Workers: 1, SnapshotMode: entity.SnapshotModeDOM, MaxSteps: 50, StepDelay: 2 * time.Second},
+		&recordingBrowser{Browser: newAgent(t)},
+		aiClient,
+		newContextManager,
+		security.NewLayer(nil, &autoApprover{}),
+		tracker,
+	)
+	if err != nil {
+		t.Fatalf("orchestrator: %v", err)
+	}
+
+	orch.RunTask(entity.PoolTask{
+		ID:      uuid.New().String(),
+		Text:    "Look at the catalog",
+		Options: entity.TaskOptions{Vision: true},
+		Ctx:     context.Background(),
+	})
+	assertSucceeded(t, *tracker.result)
+
+	calls := aiClient.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("ai calls = %d, want 2", len(calls))
+	}
+	for i, messages := range calls {
+		images := messages[len(messages)-1].Images
+		if len(images) != 1 || images[0].MediaType != "image/jpeg" || len(images[0].Data) == 0 {
+			t.Errorf("call %d: prompt has no screenshot", i)
+		}
+	}
+}
+
+func TestOrchestratorAnswerSchema(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Find the name of product 2",
+		options: entity.TaskOptions{AnswerSchema: json.RawMessage(`{
+			"type": "object",
+			"properties": {"name": {"type": "string", "minLength": 1}},
+			"required": ["name"]
+		}`)},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/product.html?id=2", Reasoning: "open product"},
+			{Action: "complete", Data: json.RawMessage(`{"title": "Blue Widget"}`), Reasoning: "wrong field"},
+			{Action: "extract", Data: json.RawMessage(`{"name": "Blue Widget"}`), Reasoning: "save the name"},
+			{Action: "complete", Text: "Blue Widget", Reasoning: "name is saved"},
+		},
+	}
+
+	result := s.run(t)
+	assertSucceeded(t, result)
+	assertActions(t, s)
+
+	if got := string(result.Data); got != `{"name": "Blue Widget"}` {
+		t.Errorf("result data = %s", got)
+	}
+
+	// Ответ не по схеме отклонён, задача продолжилась
+	if rejected := s.tracker.steps[1]; !strings.Contains(rejected.Error, "answer schema") {
+		t.Errorf("invalid complete was not rejected: %+v", rejected)
+	}
+}
+
+func TestOrchestratorSearch(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Open the Blue Widget product page",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/search.html", Reasoning: "open shop"},
+			{Action: "type", Target: "search products", Text: "blue", Reasoning: "search for blue"},
+			{Action: "click", Target: "Find", Reasoning: "run search"},
+			{Action: "click", Target: "Blue Widget", Reasoning: "open product"},
+			{Action: "complete", Reasoning: "product page is open"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	page := s.page.lastPage(t)
+	if got, want := page.CurrentURL(), site.URL+"/product.html?id=2"; got != want {
+		t.Errorf("current url = %q, want %q", got, want)
+	}
+	assertState(t, context.Background(), page, "H1: Blue Widget")
+}
+
+func TestOrchestratorPageActions(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Open the menu on the actions page, then return to the strategies page",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/strategies.html", Reasoning: "open strategies"},
+			{Action: "navigate", URL: site.URL + "/actions.html", Reasoning: "open actions"},
+			{Action: "hover", Target: "Menu", Reasoning: "open the menu"},
+			{Action: "press_key", Keys: "Escape", Reasoning: "close the menu"},
+			{Action: "go_back", Reasoning: "return"},
+			{Action: "complete", Reasoning: "back on strategies"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	page := s.page.lastPage(t)
+	if got, want := page.CurrentURL(), site.URL+"/strategies.html"; got != want {
+		t.Errorf("current url = %q, want %q", got, want)
+	}
+}
+
+func TestOrchestratorCheckout(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Buy the items in the cart",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/cart.html", Reasoning: "open cart"},
+			{Action: "click", Target: "Proceed to checkout", Reasoning: "go to shipping"},
+			{Action: "type", Target: "full name", Text: "Alice Smith", Reasoning: "fill name"},
+			{Action: "type", Target: "street address", Text: "1 Main St", Reasoning: "fill address"},
+			{Action: "click", Target: "Continue", Reasoning: "go to review"},
+			{Action: "click", Target: "Place order", Reasoning: "finish purchase", NeedApproval: true},
+			{Action: "complete", Reasoning: "order placed"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+	assertState(t, context.Background(), s.page.lastPage(t), "H1: Thank you for your order")
+
+	// Оформление и покупка проходят через подтверждение
+	if s.approve.count() == 0 {
+		t.Error("checkout was not sent for approval")
+	}
+}
+
+func TestOrchestratorPolicyApproval(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Pay for the order",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/pay.html", Reasoning: "open payment form to send the payment"},
+			{Action: "type", Target: "card number", Text: "4242424242424242", Reasoning: "fill card"},
+			{Action: "click", Target: "Оплатить", Reasoning: "pay"},
+			{Action: "complete", Reasoning: "paid"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	// Слово "send" в рассуждениях больше не требует подтверждения,
+	// ввод карты и отправка формы с ней - требуют
+	var rules []string
+	for _, approval := range s.approve.approvals {
+		rules = append(rules, approval.Action+":"+approval.Rule)
+	}
+	if want := []string{"type:payment-details", "click:payment-details"}; !slices.Equal(rules, want) {
+		t.Errorf("approvals = %v, want %v", rules, want)
+	}
+}
+
+func TestOrchestratorEnterInCardForm(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Pay for the order",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/pay.html", Reasoning: "open payment form"},
+			{Action: "type", Target: "card number", Text: "4242424242424242", Reasoning: "fill card"},
+			// Без элемента Enter уходит полю карты в фокусе и отправляет форму
+			{Action: "press_key", Keys: "Enter", Reasoning: "submit"},
+			{Action: "complete", Reasoning: "paid"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	var rules []string
+	for _, approval := range s.approve.approvals {
+		rules = append(rules, approval.Action+":"+approval.Rule)
+	}
+	if want := []string{"type:payment-details", "press_key:payment-details"}; !slices.Equal(rules, want) {
+		t.Errorf("approvals = %v, want %v", rules, want)
+	}
+	assertState(t, context.Background(), s.page.lastPage(t), "Page Title: Started")
+}
+
+func TestOrchestratorPolicyOverride(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Pay for the order",
+		options: entity.TaskOptions{Policy: `
+rules:
+  - name: no-cart
+    match: { actions: [navigate], url: 'cart\.html' }
+    outcome: deny
+  - name: trusted-payments
+    match: { field_types: [credit_card] }
+    outcome: allow
+`},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/cart.html", Reasoning: "open cart"},
+			{Action: "navigate", URL: site.URL + "/pay.html", Reasoning: "open payment form"},
+			{Action: "type", Target: "card number", Text: "4242424242424242", Reasoning: "fill card"},
+			{Action: "click", Target: "Оплатить", Reasoning: "pay"},
+			{Action: "complete", Reasoning: "paid"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	denied := s.tracker.steps[0]
+	if denied.PolicyOutcome != entity.PolicyOutcomeDeny || denied.PolicyRule != "no-cart" || !strings.Contains(denied.Error, "no-cart") {
+		t.Errorf("navigate to cart was not denied: %+v", denied)
+	}
+
+	// Правила задачи проверяются раньше общих
+	if s.approve.count() != 0 {
+		t.Errorf("approvals = %d, want none", s.approve.count())
+	}
+	if click := s.tracker.steps[3]; click.Element == nil || click.Element.Tag != "button" {
+		t.Errorf("clicked element = %+v, want the pay button", click.Element)
+	}
+}
+
+func TestOrchestratorDomainRules(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Open the partner offer",
+		options: entity.TaskOptions{Domains: entity.DomainRules{
+			Allowed: []string{"127.0.0.1"},
+		}},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: "example.com", Reasoning: "search elsewhere"},
+			{Action: "navigate", URL: "javascript:alert(1)", Reasoning: "injected"},
+			{Action: "navigate", URL: site.URL + "/external.html", Reasoning: "open partners"},
+			{Action: "click", Target: "Partner offer", Reasoning: "follow the link"},
+			{Action: "hover", Target: "Partner deals", Reasoning: "open deals"},
+			{Action: "check", Target: "Partner newsletter", Reasoning: "subscribe"},
+			{Action: "navigate", URL: site.URL + "/widgets.html", Reasoning: "open checkout"},
+			{Action: "click", Target: "Show offers", Reasoning: "see offers"},
+			{Action: "complete", Reasoning: "partner site is not allowed"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	// Нарушения не прерывают задачу, модель получает их как ошибку шага
+	for _, i := range []int{0, 1, 3, 4, 5} {
+		if step := s.tracker.steps[i]; !strings.Contains(step.Error, "is not allowed") {
+			t.Errorf("step %d was not blocked: %+v", i+1, step)
+		}
+	}
+	// Заблокированные iframe не делают ошибкой переход и клик на разрешённой странице
+	for _, i := range []int{6, 7} {
+		if step := s.tracker.steps[i]; step.Error != "" {
+			t.Errorf("step %d failed because of a blocked iframe: %+v", i+1, step)
+		}
+	}
+	page := s.page.lastPage(t)
+	assertState(t, context.Background(), page, "H2: Offers shown")
+	if got := page.CurrentURL(); got != site.URL+"/widgets.html" {
+		t.Errorf("current url = %q, want to stay on the checkout page", got)
+	}
+}
+
+func TestOrchestratorPromptInjection(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Read the reviews",
+		options: entity.TaskOptions{Policy: `
+rules:
+  - name: injected-page
+    match: { injection_suspected: true }
+    outcome: require_approval
+`},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/injection.html", Reasoning: "open reviews"},
+			{Action: "complete", Text: "five stars", Reasoning: "page asks to go elsewhere, ignoring it"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	if step := s.tracker.steps[0]; len(step.Injection) != 0 {
+		t.Errorf("blank page flagged: %+v", step.Injection)
+	}
+
+	flagged := s.tracker.steps[1]
+	if len(flagged.Injection) == 0 {
+		t.Fatal("injected page was not flagged")
+	}
+	if flagged.PolicyRule != "injected-page" || s.approve.count() != 1 {
+		t.Errorf("flagged step was not sent for approval: rule %q, approvals %d", flagged.PolicyRule, s.approve.count())
+	}
+}
+
+func TestOrchestratorVaultLogin(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	vault, err := security.OpenVault(filepath.Join(t.TempDir(), "vault.json"), "test key")
+	if err != nil {
+		t.Fatalf("open vault: %v", err)
+	}
+	for _, secret := range []struct{ name, field, value, domain string }{
+		{"site", "username", "alice", "127.0.0.1"},
+		{"site", "password", "secret", "127.0.0.1"},
+		{"bank", "password", "bank-password", "bank.example"},
+	} {
+		if err := vault.Set(secret.name, secret.field, secret.value, []string{secret.domain}); err != nil {
+			t.Fatalf("set secret: %v", err)
+		}
+	}
+
+	s := &scenario{
+		task:    "Log in",
+		vault:   vault,
+		options: entity.TaskOptions{SnapshotMode: entity.SnapshotModeAccessibility},
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/login.html", Reasoning: "open login page"},
+			{Action: "type", Target: "password", Text: "{{secret:bank.password}}", Reasoning: "phished"},
+			{Action: "type", Target: "username", Text: "{{secret:site.username}}", Reasoning: "enter user name"},
+			{Action: "type", Target: "password", Text: "{{secret:site.password}}", Reasoning: "enter password"},
+			{Action: "click", Target: "Sign in", Reasoning: "log in"},
+			{Action: "complete", Text: "logged in", Reasoning: "welcome message is shown"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+	assertState(t, context.Background(), s.page.lastPage(t), "H1: Welcome, alice")
+
+	// Секрет другого сайта не вводится, модель узнаёт об этом из ошибки шага
+	if step := s.tracker.steps[1]; !strings.Contains(step.Error, "not allowed") {
+		t.Errorf("bank password was typed on the wrong site: %+v", step)
+	}
+
+	// Модель и журнал шагов видят только плейсхолдеры
+	for _, messages := range s.client.Calls() {
+		prompt := messages[len(messages)-1].Content
+		if strings.Contains(prompt, "secret\n") || strings.Contains(prompt, ": alice") {
+			t.Errorf("secret value leaked into the prompt:\n%s", prompt)
+		}
+		if !strings.Contains(prompt, "{{secret:site.password}} for 127.0.0.1") {
+			t.Errorf("prompt does not list credentials:\n%s", prompt)
+		}
+	}
+	for _, step := range s.tracker.steps {
+		if strings.Contains(step.Action.Text, "alice") || strings.Contains(step.Error, "bank-password") {
+			t.Errorf("secret value leaked into step %d: %+v", step.Number, step)
+		}
+	}
+}
+
+func TestOrchestratorModalDialog(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Get started",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/modal.html", Reasoning: "open landing"},
+			{Action: "click", Target: "Accept cookies", Reasoning: "close the modal"},
+			{Action: "click", Target: "Get started", Reasoning: "open getting started"},
+			{Action: "complete", Reasoning: "done"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+	assertState(t, context.Background(), s.page.lastPage(t), "H1: Getting started")
+}
+
+func TestOrchestratorInfiniteScroll(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Scroll the feed",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/scroll.html", Reasoning: "open feed"},
+			{Action: "scroll", Reasoning: "load more"},
+			{Action: "scroll", Reasoning: "load more"},
+			{Action: "scroll", Reasoning: "load more"},
+			{Action: "complete", Reasoning: "done"},
+		},
+	}
+
+	assertSucceeded(t, s.run(t))
+	assertActions(t, s)
+
+	state, err := s.page.lastPage(t).ExtractPageState(context.Background(), entity.SnapshotModeDOM)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+	// Видны элементы из догруженных порций
+	if !regexp.MustCompile(`link "Item (1[1-9]|[2-5]\d)"`).MatchString(state) {
+		t.Errorf("no lazily loaded items in page state:\n%s", state)
+	}
+}
+
+func TestOrchestratorStopsOnUnknownAction(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	s := &scenario{
+		task: "Do something odd",
+		steps: []entity.AiResponse{
+			{Action: "navigate", URL: site.URL + "/cart.html", Reasoning: "open cart"},
+			{Action: "dance", Reasoning: "???"},
+		},
+	}
+
+	result := s.run(t)
+	if result.Status != entity.TaskStatusFailed {
+		t.Fatalf("status = %s, want %s", result.Status, entity.TaskStatusFailed)
+	}
+	assertActions(t, s)
+}
+
+func TestOrchestratorParallelTasksAreIsolated(t *testing.T) {
+	t.Parallel()
+	site := startSite(t)
+
+	// Первая задача оставляет cookie и запись в localStorage, вторая не должна их видеть
+	step := func(task, url string, resp entity.AiResponse) ai.MockStep {
+		return ai.MockStep{
+			Match:    &ai.MockMatch{Task: task, URL: url},
+			Response: &resp,
+			Latency:  "100ms",
+		}
+	}
+
+	aiClient, err := ai.NewMockClient(ai.MockScript{Rules: []ai.MockStep{
+		step("alice", "^about:blank$", entity.AiResponse{Action: "navigate", URL: site.URL + "/session.html?name=alice", Reasoning: "sign in as alice"}),
+		step("alice", "name=alice", entity.AiResponse{Action: "navigate", URL: site.URL + "/session.html?check=alice", Reasoning: "reload"}),
+		step("alice", "check=alice", entity.AiResponse{Action: "complete", Reasoning: "done"}),
+		step("anonymous", "^about:blank$", entity.AiResponse{Action: "navigate", URL: site.URL + "/session.html?check=anonymous", Reasoning: "open session"}),
+		step("anonymous", "check=anonymous", entity.AiResponse{Action: "complete", Reasoning: "done"}),
+	}})
+	if err != nil {
+		t.Fatalf("mock client: %v", err)
+	}
+
+	pages := &recordingBrowser{Browser: newAgent(t)}
+	t.Cleanup(pages.close)
+	tracker := &recorder{}
+	tasks := make(chan entity.PoolTask, 2)
+
+	orch, err := core.NewOrchestrator(
+		core.Config{Workers: 2, SnapshotMode: entity.SnapshotModeDOM, MaxSteps: 50, StepDelay: 2 * time.Second},
+		pages,
+		aiClient,
+		newContextManager,
+		security.NewLayer(nil, &autoApprover{}),
+		tracker,
+		tasks,
+	)
+	if err != nil {
+		t.Fatalf("orchestrator: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := orch.Start(ctx); err != nil {
+		t.Fatalf("start orchestrator: %v", err)
+	}
+	t.Cleanup(func() {
+		orch.Stop(context.Background())
+	})
+
+	tasks <- entity.PoolTask{ID: "alice", Text: "Visit as alice", Ctx: ctx}
+	tasks <- entity.PoolTask{ID: "anonymous", Text: "Visit as anonymous", Ctx: ctx}
+
+	for id, result := range tracker.wait(t, 2, 30*time.Second) {
+		if result.Status != entity.TaskStatusSucceeded {
+			t.Errorf("task %s: status = %s (%s)", id, result.Status, result.Error)
+		}
+	}
+
+	for _, want := range []struct {
+		check string
+		state string
+	}{
+		{"alice", "H1: Visitor: alice / alice"},
+		{"anonymous", "H1: Visitor: anonymous / anonymous"},
+	} {
+		page := pages.pageByURL(t, "check="+want.check)
+		assertState(t, ctx, page, want.state)
+	}
+
+	// История одной задачи не попадает в промпты другой
+	for _, messages := range aiClient.Calls() {
+		prompt := messages[len(messages)-1].Content
+		if strings.Contains(prompt, "TASK: Visit as anonymous") && strings.Contains(prompt, "alice") {
+			t.Errorf("anonymous task prompt mentions alice:\n%s", prompt)
+		}
+	}
+}
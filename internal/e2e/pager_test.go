@@ -0,0 +1,375 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/core"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+)
+
+func TestPagerNavigate(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/cart.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	if got := page.CurrentURL(); got != site.URL+"/cart.html" {
+		t.Errorf("current url = %q, want %q", got, site.URL+"/cart.html")
+	}
+}
+
+func TestPagerClickElementStrategies(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/strategies.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		description string
+		want        string
+	}{
+		{"exact text", "Sign in", "Clicked signin"},
+		{"partial text", "Add to", "Clicked add-to-cart"},
+		{"aria-label", "Close dialog", "Clicked close"},
+		{"data attributes", "checkout now", "Clicked checkout"},
+		{"link text", "Documentation", "Clicked docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := page.ClickElement(ctx, 0, tt.description); err != nil {
+				t.Fatalf("click %q: %v", tt.description, err)
+			}
+			assertState(t, ctx, page, "H2: "+tt.want)
+		})
+	}
+
+	t.Run("missing element", func(t *testing.T) {
+		err := page.ClickElement(ctx, 0, "No such thing anywhere")
+		if err == nil || !strings.Contains(err.Error(), "element not found") {
+			t.Errorf("error = %v, want element not found", err)
+		}
+	})
+}
+
+func TestPagerTypeText(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/strategies.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	tests := []struct {
+		description string
+		text        string
+		want        string
+	}{
+		{"search products", "blue", "Typed blue into search"},
+		{"leave a comment", "hello", "Typed hello into comment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if err := page.TypeText(ctx, 0, tt.description, tt.text); err != nil {
+				t.Fatalf("type into %q: %v", tt.description, err)
+			}
+			assertState(t, ctx, page, "H2: "+tt.want)
+		})
+	}
+}
+
+func TestPagerExtractPageState(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/strategies.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	assertState(t, ctx, page,
+		"Current URL: "+site.URL+"/strategies.html",
+		"Page Title: Strategies",
+		"H1: Element strategies",
+	)
+
+	state, err := page.ExtractPageState(ctx, entity.SnapshotModeDOM)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+
+	for _, pattern := range []string{
+		`--- BUTTONS ---\n\[\d+\] button "Sign in"\n\[\d+\] button "Add to cart"\n`,
+		`--- LINKS ---\n\[\d+\] link "Documentation"\n`,
+		`\[\d+\] input\[text\] "search products"\n`,
+		`\[\d+\] textarea "leave a comment"\n`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(state) {
+			t.Errorf("page state does not match %q:\n%s", pattern, state)
+		}
+	}
+}
+
+func TestPagerAccessibilitySnapshot(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/cards.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	state, err := page.ExtractPageState(ctx, entity.SnapshotModeAccessibility)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+
+	for _, pattern := range []string{
+		`Page Title: Cards\n`,
+		`--- ACCESSIBILITY TREE ---\n`,
+		// landmarks
+		`- navigation:\n\s+- \[\d+\] link "Cart"`,
+		`- main:\n`,
+		// кнопка вложена в карточку своего товара
+		`- article:\n\s+- heading "Blue Widget" \[level=2\]\n\s+- \[\d+\] button "Add to cart"`,
+		// состояния
+		`\[\d+\] button "Sold out" \[disabled\]`,
+		`\[\d+\] checkbox "Gift wrap" \[checked\]`,
+		`\[\d+\] button "Details" \[expanded\]`,
+		// shadow DOM нумеруется так же
+		`\[\d+\] button "Clear cart"\n\s+- \[\d+\] link "Open cart"`,
+		// элементы за пределами viewport
+		`\[\d+\] button "Load more"`,
+	} {
+		if !regexp.MustCompile(pattern).MatchString(state) {
+			t.Errorf("page state does not match %q:\n%s", pattern, state)
+		}
+	}
+
+	if strings.Contains(state, "[ref=") || strings.Contains(state, "/url:") {
+		t.Errorf("page state contains playwright refs or urls:\n%s", state)
+	}
+
+	// Номер из дерева доступности работает с ClickElement
+	match := regexp.MustCompile(`heading "Blue Widget" \[level=2\]\n\s+- \[(\d+)\] button`).FindStringSubmatch(state)
+	if match == nil {
+		t.Fatal("no Blue Widget button in page state")
+	}
+	id, _ := strconv.Atoi(match[1])
+
+	if err := page.ClickElement(ctx, id, ""); err != nil {
+		t.Fatalf("click [%d]: %v", id, err)
+	}
+
+	state, err = page.ExtractPageState(ctx, entity.SnapshotModeAccessibility)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+	if !strings.Contains(state, "Added Blue Widget") {
+		t.Errorf("click on [%d] did not add Blue Widget:\n%s", id, state)
+	}
+}
+
+func TestPagerScreenshot(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/cards.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	before, err := page.ExtractPageState(ctx, entity.SnapshotModeAccessibility)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+
+	image, err := page.Screenshot(ctx)
+	if err != nil {
+		t.Fatalf("screenshot: %v", err)
+	}
+	if image.MediaType != "image/jpeg" || !bytes.HasPrefix(image.Data, []byte{0xff, 0xd8, 0xff}) {
+		t.Errorf("screenshot is not a jpeg: %s, %d bytes", image.MediaType, len(image.Data))
+	}
+
+	// Рамки с номерами не остаются на странице
+	after, err := page.ExtractPageState(ctx, entity.SnapshotModeAccessibility)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+	if before != after {
+		t.Errorf("page state changed after screenshot:\n%s\n---\n%s", before, after)
+	}
+}
+
+func TestPagerScreenshotMasksSecrets(t *testing.T) {
+	t.Parallel()
+
+	vault, err := security.OpenVault(filepath.Join(t.TempDir(), "vault.json"), "test key")
+	if err != nil {
+		t.Fatalf("open vault: %v", err)
+	}
+	for name, value := range map[string]string{"short": "alice", "long": "alexander.the.great"} {
+		if err := vault.Set(name, "username", value, []string{"127.0.0.1"}); err != nil {
+			t.Fatalf("set secret: %v", err)
+		}
+	}
+
+	site := startSite(t)
+	page := newPage(t, newSecretsAgent(t, vault))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/login.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+	username := elementID(t, ctx, page, `input[text] "username"`)
+
+	// Имя пользователя видно в поле, на снимке поле закрыто и не зависит от значения
+	var images [][]byte
+	for _, secret := range []string{"{{secret:short.username}}", "{{secret:long.username}}"} {
+		if err := page.TypeText(ctx, username, "", secret); err != nil {
+			t.Fatalf("type %s: %v", secret, err)
+		}
+		image, err := page.Screenshot(ctx)
+		if err != nil {
+			t.Fatalf("screenshot: %v", err)
+		}
+		images = append(images, image.Data)
+	}
+	if !bytes.Equal(images[0], images[1]) {
+		t.Error("screenshot shows the typed secret")
+	}
+}
+
+func TestPagerSecretNeedsField(t *testing.T) {
+	t.Parallel()
+
+	vault, err := security.OpenVault(filepath.Join(t.TempDir(), "vault.json"), "test key")
+	if err != nil {
+		t.Fatalf("open vault: %v", err)
+	}
+	if err := vault.Set("shop", "password", "hunter2", []string{"127.0.0.1"}); err != nil {
+		t.Fatalf("set secret: %v", err)
+	}
+
+	site := startSite(t)
+	page := newPage(t, newSecretsAgent(t, vault))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/search.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	// Поля пароля нет, секрет не вводится в первое попавшееся поле поиска
+	if err := page.TypeText(ctx, 0, "Password", "{{secret:shop.password}}"); err == nil {
+		t.Fatal("secret typed into a guessed field")
+	}
+
+	// Обычный текст по-прежнему вводится в первое поле
+	if err := page.TypeText(ctx, 0, "Password", "red"); err != nil {
+		t.Fatalf("type into guessed field: %v", err)
+	}
+	if err := page.ClickElement(ctx, 0, "Find"); err != nil {
+		t.Fatalf("click Find: %v", err)
+	}
+	assertState(t, ctx, page, "Red Widget")
+}
+
+func TestPagerElementIDs(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	page := newPage(t, newAgent(t))
+	ctx := context.Background()
+
+	if err := page.Navigate(ctx, site.URL+"/strategies.html"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+
+	addToCart := elementID(t, ctx, page, `button "Add to cart"`)
+	search := elementID(t, ctx, page, `input[text] "search products"`)
+
+	if err := page.ClickElement(ctx, addToCart, ""); err != nil {
+		t.Fatalf("click [%d]: %v", addToCart, err)
+	}
+	assertState(t, ctx, page, "H2: Clicked add-to-cart")
+
+	if err := page.TypeText(ctx, search, "", "red"); err != nil {
+		t.Fatalf("type into [%d]: %v", search, err)
+	}
+	assertState(t, ctx, page, "H2: Typed red into search")
+
+	// Номера не меняются между снимками одной страницы
+	if id := elementID(t, ctx, page, `button "Add to cart"`); id != addToCart {
+		t.Errorf("element id changed from %d to %d", addToCart, id)
+	}
+
+	err := page.ClickElement(ctx, 999, "")
+	if err == nil || !strings.Contains(err.Error(), "element not found") {
+		t.Errorf("error = %v, want element not found", err)
+	}
+}
+
+// elementID находит номер элемента в состоянии страницы
+func elementID(t *testing.T, ctx context.Context, page core.Page, element string) int {
+	t.Helper()
+
+	state, err := page.ExtractPageState(ctx, entity.SnapshotModeDOM)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+
+	match := regexp.MustCompile(`\[(\d+)\] ` + regexp.QuoteMeta(element)).FindStringSubmatch(state)
+	if match == nil {
+		t.Fatalf("no %s in page state:\n%s", element, state)
+	}
+
+	id, _ := strconv.Atoi(match[1])
+	return id
+}
+
+// assertState проверяет, что состояние страницы содержит все подстроки
+func assertState(t *testing.T, ctx context.Context, page interface {
+	ExtractPageState(ctx context.Context, mode entity.SnapshotMode) (string, error)
+}, want ...string) {
+	t.Helper()
+
+	state, err := page.ExtractPageState(ctx, entity.SnapshotModeDOM)
+	if err != nil {
+		t.Fatalf("extract page state: %v", err)
+	}
+
+	for _, w := range want {
+		if !strings.Contains(state, w) {
+			t.Errorf("page state does not contain %q:\n%s", w, state)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/agent/browser"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/store/local"
+)
+
+func TestPagerProfile(t *testing.T) {
+	t.Parallel()
+
+	site := startSite(t)
+	ctx := context.Background()
+
+	store, err := local.NewProfileStore(local.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+	agent := newBrowserAgent(t, browser.Config{Headless: true, Profile: "default"}, nil, store)
+
+	visit := func(profile, query, want string) {
+		t.Helper()
+
+		page, err := agent.NewPage(entity.TaskOptions{Profile: profile})
+		if err != nil {
+			t.Fatalf("new page: %v", err)
+		}
+		if err := page.Navigate(ctx, site.URL+"/session.html?"+query); err != nil {
+			t.Fatalf("navigate: %v", err)
+		}
+		assertState(t, ctx, page, "H1: Visitor: "+want)
+
+		// Сессии сохраняются при закрытии страницы
+		if err := page.Close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+	}
+
+	visit("shop", "name=alice", "alice / alice")
+	visit("shop", "check=alice", "alice / alice")
+	visit("", "check=default", "anonymous / anonymous")
+	visit("", "name=bob", "bob / bob")
+	visit("", "check=default", "bob / bob")
+	visit("shop", "check=shop", "alice / alice")
+
+	profiles, err := store.ListProfiles(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(profiles) != 2 || profiles[1].Name != "shop" || profiles[1].Cookies != 1 || profiles[1].Origins != 1 {
+		t.Errorf("profiles = %+v", profiles)
+	}
+
+	// Удалённый профиль начинается без сессий
+	if err := store.DeleteProfile(ctx, "shop"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	visit("shop", "check=wiped", "anonymous / anonymous")
+
+	// Профиль, удалённый во время задачи, закрытие страницы не восстанавливает
+	page, err := agent.NewPage(entity.TaskOptions{Profile: "wiped"})
+	if err != nil {
+		t.Fatalf("new page: %v", err)
+	}
+	if err := page.Navigate(ctx, site.URL+"/session.html?name=carol"); err != nil {
+		t.Fatalf("navigate: %v", err)
+	}
+	visit("wiped", "name=dave", "dave / dave")
+	if err := store.DeleteProfile(ctx, "wiped"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := page.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, _, err := store.LoadProfile(ctx, "wiped"); !errors.Is(err, entity.ErrProfileNotFound) {
+		t.Errorf("wiped profile restored: %v", err)
+	}
+}
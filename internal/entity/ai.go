@@ -1,16 +1,44 @@
 package entity
 
+import "encoding/json"
+
 type AiMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Images части сообщения для мультимодальных моделей, идут после текста Content.
+	// Провайдеры без vision отправляют только текст.
+	Images []AiImage `json:"-"`
+}
+
+// AiImage изображение в сообщении модели
+type AiImage struct {
+	// MediaType например image/jpeg
+	MediaType string
+	Data      []byte
 }
 
 type AiResponse struct {
 	Reasoning    string `json:"reasoning"`
 	Action       string `json:"action"`
 	Target       string `json:"target,omitempty"`
+	ElementID    int    `json:"element_id,omitempty"`
 	Text         string `json:"text,omitempty"`
 	URL          string `json:"url,omitempty"`
 	NeedApproval bool   `json:"need_approval,omitempty"`
 	Completed    bool   `json:"completed,omitempty"`
+	// Option значение или подпись варианта для select
+	Option string `json:"option,omitempty"`
+	// Keys клавиша или сочетание для press_key, например Enter или Control+A
+	Keys string `json:"keys,omitempty"`
+	// File имя файла из каталога загрузок для upload
+	File string `json:"file,omitempty"`
+	// Checked состояние флажка для check, пусто - отметить
+	Checked *bool `json:"checked,omitempty"`
+	// Data структурированный ответ действий extract и complete
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// IsChecked состояние, в которое действие check переводит флажок
+func (resp AiResponse) IsChecked() bool {
+	return resp.Checked == nil || *resp.Checked
 }
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrApprovalNotFound = errors.New("approval not found")
+	ErrApprovalExpired  = errors.New("approval expired")
+)
+
+type ApprovalKind string
+
+const (
+	// ApprovalKindAction подтверждение чувствительного действия
+	ApprovalKindAction ApprovalKind = "action"
+	// ApprovalKindUserAction ожидание ручных действий пользователя в браузере (wait_user)
+	ApprovalKindUserAction ApprovalKind = "user_action"
+)
+
+type ApprovalStatus string
+
+const (
+	ApprovalStatusPending   ApprovalStatus = "pending"
+	ApprovalStatusApproved  ApprovalStatus = "approved"
+	ApprovalStatusRejected  ApprovalStatus = "rejected"
+	ApprovalStatusExpired   ApprovalStatus = "expired"
+	ApprovalStatusCancelled ApprovalStatus = "cancelled"
+)
+
+// Approval запрос подтверждения от человека
+type Approval struct {
+	ID        string
+	TaskID    string
+	Kind      ApprovalKind
+	Action    string
+	Target    string
+	Reasoning string
+	PageURL   string
+	// Rule правило политики, потребовавшее подтверждения
+	Rule string
+	// ModelRequested подтверждение запросила модель флагом need_approval
+	ModelRequested bool
+	Status         ApprovalStatus
+	Comment        string
+
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	ResolvedAt time.Time
+}
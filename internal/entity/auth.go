@@ -0,0 +1,30 @@
+package entity
+
+// AuthSignals признаки входа в аккаунт, найденные на странице
+type AuthSignals struct {
+	// Status HTTP статус основного документа, 0 если неизвестен
+	Status int
+	// Redirected документ получен после редиректа
+	Redirected bool
+	// PasswordFields число видимых полей ввода пароля
+	PasswordFields int
+	// Login тексты элементов входа: "Sign in", "Войти"
+	Login []string
+	// Logout тексты элементов выхода: "Log out", "Выйти"
+	Logout []string
+	// Account меню аккаунта или аватар пользователя
+	Account []string
+	// Username имя пользователя, если оно есть на странице
+	Username string
+}
+
+// AuthState состояние авторизации на домене
+type AuthState struct {
+	IsLoggedIn bool
+	Username   string
+	Domain     string
+	// AuthRequired страница требует входа: форма логина, 401/403, редирект на вход
+	AuthRequired bool
+	// Reason признак, по которому определено состояние, пустой - состояние неизвестно
+	Reason string
+}
@@ -1,6 +1,54 @@
 package entity
 
+import (
+	"context"
+	"encoding/json"
+)
+
 type PoolTask struct {
-	ID   string
-	Text string
+	ID      string
+	Text    string
+	Options TaskOptions
+	// Steps шаги, выполненные до перезапуска агента, нумерация продолжается после них
+	Steps int
+	// Ctx контекст задачи, отменяется при CancelTask
+	Ctx context.Context
+}
+
+// TaskOptions переопределения настроек для отдельной задачи
+type TaskOptions struct {
+	// Model модель LLM вместо модели по умолчанию
+	Model string `json:"model,omitempty"`
+	// SnapshotMode представление страницы для модели, пусто - по умолчанию агента
+	SnapshotMode SnapshotMode `json:"snapshot_mode,omitempty"`
+	// Vision прикладывать к состоянию страницы скриншот с номерами элементов
+	Vision bool `json:"vision,omitempty"`
+	// AnswerSchema JSON схема структурированного ответа задачи
+	AnswerSchema json.RawMessage `json:"answer_schema,omitempty"`
+	// Policy YAML политика безопасности, её правила проверяются раньше общих
+	Policy string `json:"policy,omitempty"`
+	// Domains ограничения переходов задачи, действуют вместе с общими
+	Domains DomainRules `json:"domains,omitempty"`
+	// Profile профиль с сохранёнными сессиями, пусто - профиль агента по умолчанию
+	Profile string `json:"profile,omitempty"`
+	// Browser настройки браузера задачи поверх настроек агента
+	Browser BrowserOptions `json:"browser,omitempty"`
+}
+
+// SnapshotMode способ извлечения состояния страницы
+type SnapshotMode string
+
+const (
+	// SnapshotModeDOM список видимых интерактивных элементов
+	SnapshotModeDOM SnapshotMode = "dom"
+	// SnapshotModeAccessibility дерево доступности: роли, имена, состояния, вложенность
+	SnapshotModeAccessibility SnapshotMode = "accessibility"
+)
+
+func (m SnapshotMode) IsValid() bool {
+	switch m {
+	case SnapshotModeDOM, SnapshotModeAccessibility:
+		return true
+	}
+	return false
 }
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DomainRules списки доменов, куда агенту можно и нельзя переходить.
+// Запрет сильнее разрешения, пустой Allowed разрешает все домены.
+type DomainRules struct {
+	Allowed []string `json:"allowed,omitempty"`
+	Blocked []string `json:"blocked,omitempty"`
+}
+
+func (r DomainRules) IsEmpty() bool {
+	return len(r.Allowed) == 0 && len(r.Blocked) == 0
+}
+
+// Check проверяет адрес. Не http(s) адреса (about:blank, data:) не проверяются.
+func (r DomainRules) Check(rawURL string) error {
+	host, ok := httpHost(rawURL)
+	if !ok {
+		return nil
+	}
+
+	if pattern, blocked := matchAny(r.Blocked, host); blocked {
+		return &DomainError{URL: rawURL, Domain: host, Rule: "blocked by " + pattern}
+	}
+	if _, allowed := matchAny(r.Allowed, host); len(r.Allowed) > 0 && !allowed {
+		return &DomainError{URL: rawURL, Domain: host, Rule: "not in the allowed list"}
+	}
+	return nil
+}
+
+// Validate проверяет, что шаблоны - имена хостов без схемы, порта и пути
+func (r DomainRules) Validate() error {
+	for _, pattern := range slices.Concat(r.Allowed, r.Blocked) {
+		host := strings.TrimPrefix(pattern, "*.")
+		if host == "" || strings.ContainsAny(host, "/:*@ ") {
+			return fmt.Errorf("invalid domain pattern: %q", pattern)
+		}
+	}
+	return nil
+}
+
+// CheckBlocked проверяет адрес только по списку запрещённых доменов
+func (r DomainRules) CheckBlocked(rawURL string) error {
+	return DomainRules{Blocked: r.Blocked}.Check(rawURL)
+}
+
+// DomainError переход на запрещённый домен
+type DomainError struct {
+	URL    string
+	Domain string
+	// Rule почему домен запрещён
+	Rule string
+}
+
+func (e *DomainError) Error() string {
+	if e.Domain == "" {
+		return fmt.Sprintf("url %s is not allowed (%s)", e.URL, e.Rule)
+	}
+	return fmt.Sprintf("domain %s is not allowed (%s)", e.Domain, e.Rule)
+}
+
+// MatchDomain сравнивает хост с шаблоном: example.com - только сам домен,
+// *.example.com - домен и его поддомены
+func MatchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	if base, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == base || strings.HasSuffix(host, "."+base)
+	}
+	return host == pattern
+}
+
+func matchAny(patterns []string, host string) (string, bool) {
+	i := slices.IndexFunc(patterns, func(pattern string) bool {
+		return MatchDomain(pattern, host)
+	})
+	if i < 0 {
+		return "", false
+	}
+	return patterns[i], true
+}
+
+func httpHost(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return "", false
+	}
+	return u.Hostname(), true
+}
@@ -0,0 +1,41 @@
+package entity_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+func TestDomainRules(t *testing.T) {
+	t.Parallel()
+
+	rules := entity.DomainRules{
+		Allowed: []string{"*.shop.example", "docs.example"},
+		Blocked: []string{"admin.shop.example"},
+	}
+
+	for url, allowed := range map[string]bool{
+		"https://shop.example/":           true,
+		"https://www.shop.example/cart":   true,
+		"http://docs.example/guide":       true,
+		"https://admin.shop.example/":     false,
+		"https://api.docs.example/":       false,
+		"https://shop.example.evil.test/": false,
+		"about:blank":                     true,
+	} {
+		err := rules.Check(url)
+		if allowed != (err == nil) {
+			t.Errorf("%s: err = %v, want allowed = %v", url, err, allowed)
+		}
+
+		var domainErr *entity.DomainError
+		if err != nil && !errors.As(err, &domainErr) {
+			t.Errorf("%s: error %T is not a DomainError", url, err)
+		}
+	}
+
+	if err := (entity.DomainRules{Blocked: []string{"https://evil.test/"}}).Validate(); err == nil {
+		t.Error("url accepted as a domain pattern")
+	}
+}
@@ -0,0 +1,70 @@
+package entity
+
+// PolicyOutcome решение политики безопасности по действию
+type PolicyOutcome string
+
+const (
+	PolicyOutcomeAllow           PolicyOutcome = "allow"
+	PolicyOutcomeRequireApproval PolicyOutcome = "require_approval"
+	PolicyOutcomeDeny            PolicyOutcome = "deny"
+)
+
+func (o PolicyOutcome) IsValid() bool {
+	switch o {
+	case PolicyOutcomeAllow, PolicyOutcomeRequireApproval, PolicyOutcomeDeny:
+		return true
+	}
+	return false
+}
+
+// PolicyDisagreement расхождение оценки действия моделью (need_approval) и политикой
+type PolicyDisagreement string
+
+const (
+	// PolicyDisagreementModelOnly модель просила подтверждение, политика разрешала действие
+	PolicyDisagreementModelOnly PolicyDisagreement = "model_only"
+	// PolicyDisagreementPolicyOnly политика потребовала подтверждения или запретила действие,
+	// модель подтверждения не просила
+	PolicyDisagreementPolicyOnly PolicyDisagreement = "policy_only"
+)
+
+// ActionVerdict итог проверки действия слоем безопасности
+type ActionVerdict struct {
+	// Outcome итоговое решение с учётом need_approval
+	Outcome PolicyOutcome
+	// Rule имя сработавшего правила, пусто - решение по умолчанию
+	Rule string
+	// ModelRequested модель пометила действие need_approval
+	ModelRequested bool
+	// Disagreement пусто, если модель и политика согласны
+	Disagreement PolicyDisagreement
+	// Approved действие можно выполнять
+	Approved bool
+	// Reason почему действие не подтверждено, пусто - отказ пользователя
+	Reason string
+}
+
+// FormField поле формы, по которому политика определяет тип данных
+type FormField struct {
+	Type         string `json:"type,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+}
+
+// Element элемент страницы, над которым выполняется действие
+type Element struct {
+	FormField
+	Tag  string `json:"tag"`
+	Text string `json:"text,omitempty"`
+	// Selector описание вида button#pay.btn[name=submit]
+	Selector string `json:"selector,omitempty"`
+	// Form поля формы, в которую входит элемент
+	Form []FormField `json:"form,omitempty"`
+}
+
+// InjectionFinding текст страницы, похожий на инструкции для агента
+type InjectionFinding struct {
+	// Pattern название сработавшей эвристики
+	Pattern string `json:"pattern"`
+	Snippet string `json:"snippet"`
+}
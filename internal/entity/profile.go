@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	// ErrProfileChanged профиль изменён или удалён после загрузки
+	ErrProfileChanged = errors.New("profile changed since it was loaded")
+	// ErrInvalidStorageState storage state не в формате playwright
+	ErrInvalidStorageState = errors.New("invalid storage state")
+)
+
+// profileName имя профиля используется как имя файла
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Profile сохранённое состояние браузера: cookies и localStorage.
+// Задачи одного профиля используют сессии друг друга.
+type Profile struct {
+	Name string `json:"name"`
+	// Cookies число cookies, Origins число сайтов с localStorage
+	Cookies   int       `json:"cookies"`
+	Origins   int       `json:"origins"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Version хеш содержимого, меняется при каждой записи
+	Version string `json:"version"`
+}
+
+func ValidateProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
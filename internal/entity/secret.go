@@ -0,0 +1,16 @@
+package entity
+
+import "errors"
+
+var (
+	ErrSecretNotFound   = errors.New("secret not found")
+	ErrSecretNotAllowed = errors.New("secret is not allowed on this page")
+)
+
+// SecretRef плейсхолдер секрета, который видит модель
+type SecretRef struct {
+	// Placeholder например {{secret:github.password}}
+	Placeholder string
+	// Domains сайты, на которых секрет можно ввести
+	Domains []string
+}
@@ -0,0 +1,105 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskFinished = errors.New("task already finished")
+)
+
+type TaskStatus string
+
+const (
+	TaskStatusQueued    TaskStatus = "queued"
+	TaskStatusRunning   TaskStatus = "running"
+	TaskStatusSucceeded TaskStatus = "succeeded"
+	TaskStatusFailed    TaskStatus = "failed"
+	TaskStatusCancelled TaskStatus = "cancelled"
+	TaskStatusStepLimit TaskStatus = "step_limit"
+)
+
+// IsTerminal сообщает, что задача больше не будет выполняться
+func (s TaskStatus) IsTerminal() bool {
+	switch s {
+	case TaskStatusSucceeded, TaskStatusFailed, TaskStatusCancelled, TaskStatusStepLimit:
+		return true
+	}
+	return false
+}
+
+type Task struct {
+	ID         string      `json:"id"`
+	Text       string      `json:"text"`
+	Options    TaskOptions `json:"options"`
+	Status     TaskStatus  `json:"status"`
+	Steps      int         `json:"steps"`
+	LastAction *AiResponse `json:"last_action,omitempty"`
+	Answer     string      `json:"answer,omitempty"`
+	Reasoning  string      `json:"reasoning,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	// Result структурированный ответ по TaskOptions.AnswerSchema
+	Result json.RawMessage `json:"result,omitempty"`
+	// Restarts сколько раз задача была прервана перезапуском агента
+	Restarts  int       `json:"restarts,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskResult итог выполнения задачи оркестратором
+type TaskResult struct {
+	Status    TaskStatus
+	Answer    string
+	Reasoning string
+	Error     string
+	// Data структурированный ответ из extract или complete
+	Data json.RawMessage
+}
+
+// TaskStep сведения об одном шаге выполнения задачи
+type TaskStep struct {
+	Number  int        `json:"number"`
+	PageURL string     `json:"page_url"`
+	Action  AiResponse `json:"action"`
+	// Element элемент, над которым выполняется действие, если он известен
+	Element *Element `json:"element,omitempty"`
+	// Approved вердикт слоя безопасности
+	Approved bool `json:"approved"`
+	// Policy решение политики и сработавшее правило
+	PolicyOutcome PolicyOutcome `json:"policy_outcome,omitempty"`
+	PolicyRule    string        `json:"policy_rule,omitempty"`
+	// Injection признаки инструкций для агента в содержимом страницы
+	Injection []InjectionFinding `json:"injection,omitempty"`
+	// PolicyDisagreement расхождение модели и политики, для настройки обеих
+	PolicyDisagreement PolicyDisagreement `json:"policy_disagreement,omitempty"`
+	Error              string             `json:"error,omitempty"`
+	// Recovery стратегия восстановления после ошибки, если применялась
+	Recovery  string `json:"recovery,omitempty"`
+	Recovered bool   `json:"recovered,omitempty"`
+}
+
+type TaskEventType string
+
+const (
+	TaskEventStatus   TaskEventType = "status"
+	TaskEventStep     TaskEventType = "step"
+	TaskEventApproval TaskEventType = "approval"
+)
+
+type TaskEvent struct {
+	TaskID string
+	Seq    int
+	Type   TaskEventType
+	Time   time.Time
+
+	// Status заполняется для TaskEventStatus, Result - если статус терминальный
+	Status TaskStatus
+	Result *TaskResult
+	// Step заполняется для TaskEventStep
+	Step *TaskStep
+	// Approval заполняется для TaskEventApproval
+	Approval *Approval
+}
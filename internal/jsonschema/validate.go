@@ -0,0 +1,369 @@
+// Package jsonschema реализует проверку значений по подмножеству JSON Schema:
+// type, properties, required, additionalProperties, items, enum,
+// minLength/maxLength, minimum/maximum, minItems/maxItems.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type Schema = map[string]any
+
+// ValidationError описывает первое найденное несоответствие схеме
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidateJSON разбирает data и проверяет по схеме
+func ValidateJSON(schema Schema, data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return &ValidationError{Message: fmt.Sprintf("invalid json: %v", err)}
+	}
+	return Validate(schema, value)
+}
+
+// Validate проверяет значение, полученное через encoding/json в any
+func Validate(schema Schema, value any) error {
+	return validate(schema, value, "")
+}
+
+// Parse разбирает схему из JSON. Ключевые слова вне поддерживаемого подмножества
+// отклоняются: молча пропущенное ограничение пропустило бы неверный ответ.
+func Parse(data []byte) (Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %v", err)
+	}
+	if schema == nil {
+		return nil, fmt.Errorf("invalid json schema: schema must be an object")
+	}
+	if err := check(schema, ""); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %v", err)
+	}
+	return schema, nil
+}
+
+// annotations ключевые слова, не влияющие на проверку
+var annotations = []string{"$schema", "title", "description", "default", "examples"}
+
+var types = []string{"object", "array", "string", "boolean", "number", "integer", "null"}
+
+// check проверяет, что схема использует только поддерживаемые ключевые слова
+func check(schema Schema, path string) error {
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := schema[key]
+		at := join(path, key)
+
+		switch key {
+		case "type":
+			if err := checkTypeKeyword(value, at); err != nil {
+				return err
+			}
+		case "properties":
+			properties, ok := value.(map[string]any)
+			if !ok {
+				return fail(at, "must be an object")
+			}
+			names := make([]string, 0, len(properties))
+			for name := range properties {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if err := checkSubschema(properties[name], join(at, name)); err != nil {
+					return err
+				}
+			}
+		case "required":
+			items, ok := value.([]any)
+			if !ok {
+				return fail(at, "must be an array of strings")
+			}
+			for _, item := range items {
+				if _, ok := item.(string); !ok {
+					return fail(at, "must be an array of strings")
+				}
+			}
+		case "additionalProperties":
+			if _, ok := value.(bool); ok {
+				continue
+			}
+			if err := checkSubschema(value, at); err != nil {
+				return err
+			}
+		case "items":
+			if err := checkSubschema(value, at); err != nil {
+				return err
+			}
+		case "enum":
+			if _, ok := value.([]any); !ok {
+				return fail(at, "must be an array")
+			}
+		case "minLength", "maxLength", "minimum", "maximum", "minItems", "maxItems":
+			if _, ok := number(value); !ok {
+				return fail(at, "must be a number")
+			}
+		default:
+			if !slices.Contains(annotations, key) {
+				return fail(at, "unsupported keyword")
+			}
+		}
+	}
+	return nil
+}
+
+func checkSubschema(value any, path string) error {
+	schema, ok := value.(map[string]any)
+	if !ok {
+		return fail(path, "must be a schema object")
+	}
+	return check(schema, path)
+}
+
+func checkTypeKeyword(value any, path string) error {
+	var names []any
+	switch v := value.(type) {
+	case string:
+		names = []any{v}
+	case []any:
+		names = v
+	}
+	if len(names) == 0 {
+		return fail(path, "must be a type name or an array of type names")
+	}
+
+	for _, name := range names {
+		typ, ok := name.(string)
+		if !ok || !slices.Contains(types, typ) {
+			return fail(path, "unknown type %v, expected one of %s", name, strings.Join(types, ", "))
+		}
+	}
+	return nil
+}
+
+func validate(schema Schema, value any, path string) error {
+	if schema == nil {
+		return nil
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(normalize(allowed), normalize(value)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fail(path, "value %v is not one of %v", value, enum)
+		}
+	}
+
+	if t, ok := schema["type"]; ok {
+		if err := checkType(t, value, path); err != nil {
+			return err
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		return validateObject(schema, v, path)
+	case []any:
+		return validateArray(schema, v, path)
+	case string:
+		length := len([]rune(v))
+		if min, ok := number(schema["minLength"]); ok && float64(length) < min {
+			return fail(path, "string is shorter than %v", min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && float64(length) > max {
+			return fail(path, "string is longer than %v", max)
+		}
+	case float64:
+		if min, ok := number(schema["minimum"]); ok && v < min {
+			return fail(path, "%v is less than minimum %v", v, min)
+		}
+		if max, ok := number(schema["maximum"]); ok && v > max {
+			return fail(path, "%v is greater than maximum %v", v, max)
+		}
+	}
+
+	return nil
+}
+
+func validateObject(schema Schema, obj map[string]any, path string) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, exists := obj[name]; !exists {
+				return fail(join(path, name), "required property is missing")
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+
+	// Сортируем ключи, чтобы ошибка была детерминированной
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		propSchema, known := properties[key].(map[string]any)
+		if !known {
+			if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				return fail(join(path, key), "unexpected property")
+			}
+			if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				if err := validate(additional, obj[key], join(path, key)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := validate(propSchema, obj[key], join(path, key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateArray(schema Schema, arr []any, path string) error {
+	if min, ok := number(schema["minItems"]); ok && float64(len(arr)) < min {
+		return fail(path, "array has fewer than %v items", min)
+	}
+	if max, ok := number(schema["maxItems"]); ok && float64(len(arr)) > max {
+		return fail(path, "array has more than %v items", max)
+	}
+
+	items, ok := schema["items"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	for i, item := range arr {
+		if err := validate(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkType(t any, value any, path string) error {
+	var types []string
+	switch tt := t.(type) {
+	case string:
+		types = []string{tt}
+	case []any:
+		for _, item := range tt {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	for _, typ := range types {
+		if hasType(typ, value) {
+			return nil
+		}
+	}
+	return fail(path, "expected %s, got %s", strings.Join(types, " or "), typeName(value))
+}
+
+func hasType(typ string, value any) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalize приводит числа к float64, чтобы схемы из Go литералов сравнивались с JSON
+func normalize(value any) any {
+	if n, ok := number(value); ok {
+		return n
+	}
+	return value
+}
+
+func number(value any) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func fail(path, format string, args ...any) error {
+	return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,50 @@
+package jsonschema
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	schema, err := Parse([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"title": "Product",
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "description": "Product name"},
+			"price": {"type": ["number", "null"], "minimum": 0},
+			"tags": {"type": "array", "items": {"enum": ["new", "sale"]}, "maxItems": 3}
+		},
+		"required": ["name"],
+		"additionalProperties": {"type": "string"}
+	}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if err := ValidateJSON(schema, []byte(`{"name": "Widget", "price": null, "tags": ["sale"], "note": "ok"}`)); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+
+	// Неподдерживаемое ограничение не должно молча пропускать неверные ответы
+	for schema, want := range map[string]string{
+		`null`:                        "must be an object",
+		`{"type": "strnig"}`:          "type: unknown type strnig",
+		`{"type": 1}`:                 "type: must be a type name",
+		`{"type": ["string", 1]}`:     "type: unknown type 1",
+		`{"pattern": "^a"}`:           "pattern: unsupported keyword",
+		`{"oneOf": [{}]}`:             "oneOf: unsupported keyword",
+		`{"$ref": "#/defs/a"}`:        "$ref: unsupported keyword",
+		`{"required": "name"}`:        "required: must be an array of strings",
+		`{"minimum": "1"}`:            "minimum: must be a number",
+		`{"items": true}`:             "items: must be a schema object",
+		`{"additionalProperties": 1}`: "additionalProperties: must be a schema object",
+		`{"properties": {"a": {"type": "object", "properties": {"b": {"format": "email"}}}}}`: "properties.a.properties.b.format: unsupported keyword",
+	} {
+		_, err := Parse([]byte(schema))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parse %s: error = %v, want %q", schema, err, want)
+		}
+	}
+}
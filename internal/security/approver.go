@@ -0,0 +1,134 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// Approver принимает решение по запросу подтверждения.
+// Блокируется до решения, истечения таймаута или отмены ctx.
+type Approver interface {
+	Approve(ctx context.Context, approval entity.Approval) (bool, error)
+}
+
+type ApprovalMode string
+
+const (
+	ApprovalModeRemote ApprovalMode = "remote"
+	ApprovalModeStdin  ApprovalMode = "stdin"
+)
+
+type Config struct {
+	ApprovalMode    ApprovalMode  `env:"APPROVAL_MODE" env-default:"remote"`
+	ApprovalTimeout time.Duration `env:"APPROVAL_TIMEOUT" env-default:"10m"`
+	// PolicyFile YAML политика безопасности, пусто - встроенная
+	PolicyFile string `env:"SECURITY_POLICY"`
+	// InjectionApproval подтверждать шаги, на странице которых найдены признаки prompt injection
+	InjectionApproval bool `env:"SECURITY_INJECTION_APPROVAL" env-default:"false"`
+	// VaultFile зашифрованное хранилище учётных данных
+	VaultFile string `env:"VAULT_FILE" env-default:"data/vault.json"`
+	// VaultKey пароль хранилища, пусто - хранилище не используется
+	VaultKey string `env:"VAULT_KEY"`
+}
+
+func (conf Config) Validate() error {
+	switch conf.ApprovalMode {
+	case ApprovalModeRemote, ApprovalModeStdin:
+	default:
+		return fmt.Errorf("unknown approval mode: %q", conf.ApprovalMode)
+	}
+	if conf.ApprovalTimeout <= 0 {
+		return fmt.Errorf("invalid approval timeout: %v", conf.ApprovalTimeout)
+	}
+	if conf.VaultKey != "" && conf.VaultFile == "" {
+		return fmt.Errorf("VAULT_KEY is set without VAULT_FILE")
+	}
+	return nil
+}
+
+// StdinApprover спрашивает подтверждение в терминале, для локального запуска
+type StdinApprover struct {
+	mu sync.Mutex
+
+	input  io.Reader
+	output io.Writer
+	// lines строки терминала, их читает одна горутина на всё время работы:
+	// читающая горутина на каждый запрос оставалась бы висеть после отмены ctx
+	// и забирала бы ответ на следующий запрос
+	lines chan string
+	once  sync.Once
+}
+
+func NewStdinApprover() *StdinApprover {
+	return newStdinApprover(os.Stdin, os.Stdout)
+}
+
+func newStdinApprover(input io.Reader, output io.Writer) *StdinApprover {
+	return &StdinApprover{
+		input:  input,
+		output: output,
+		lines:  make(chan string),
+	}
+}
+
+// read отправляет строки ввода в lines, закрывает канал по концу ввода
+func (sa *StdinApprover) read() {
+	scanner := bufio.NewScanner(sa.input)
+	for scanner.Scan() {
+		sa.lines <- strings.TrimSpace(scanner.Text())
+	}
+	close(sa.lines)
+}
+
+func (sa *StdinApprover) Approve(ctx context.Context, approval entity.Approval) (bool, error) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+
+	sa.once.Do(func() { go sa.read() })
+	// Строка, введённая без запроса (например, после отменённого), не ответ на этот
+	select {
+	case <-sa.lines:
+	default:
+	}
+
+	switch approval.Kind {
+	case entity.ApprovalKindUserAction:
+		fmt.Fprintf(sa.output, "\n🚨 AUTHENTICATION REQUIRED 🚨\n")
+		fmt.Fprintf(sa.output, "Reason: %s\n", approval.Reasoning)
+		fmt.Fprintf(sa.output, "Current URL: %s\n", approval.PageURL)
+		fmt.Fprintf(sa.output, "\nPlease manually authenticate in the browser and then:\n")
+		fmt.Fprintf(sa.output, "1. Complete the login process\n")
+		fmt.Fprintf(sa.output, "2. Return to the relevant page\n")
+		fmt.Fprintf(sa.output, "3. Press Enter here to continue...\n\n")
+	default:
+		fmt.Fprintf(sa.output, "\n🚨 SECURITY ALERT 🚨\n")
+		fmt.Fprintf(sa.output, "Action: %s %s\n", approval.Action, approval.Target)
+		fmt.Fprintf(sa.output, "Reasoning: %s\n", approval.Reasoning)
+		if approval.Rule != "" {
+			fmt.Fprintf(sa.output, "Policy rule: %s\n", approval.Rule)
+		}
+		if approval.Rule == InjectionRule {
+			fmt.Fprintf(sa.output, "The page contains text that looks like instructions for the agent.\n")
+		}
+		if approval.ModelRequested {
+			fmt.Fprintf(sa.output, "The model marked this action as needing approval.\n")
+		}
+		fmt.Fprintf(sa.output, "This appears to be a sensitive action.\n")
+	}
+	fmt.Fprint(sa.output, "Do you want to proceed? (y/n): ")
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case response := <-sa.lines:
+		return strings.ToLower(response) == "y", nil
+	}
+}
@@ -0,0 +1,71 @@
+package security
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+func TestStdinApprover(t *testing.T) {
+	input, terminal := io.Pipe()
+	t.Cleanup(func() { terminal.Close() })
+
+	prompts := promptWriter(make(chan struct{}, 1))
+	approver := newStdinApprover(input, prompts)
+	approval := entity.Approval{Action: "click", Target: "Pay"}
+
+	// Отменённый запрос не оставляет читателя, который заберёт следующий ответ
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := approver.Approve(ctx, approval); err != context.DeadlineExceeded {
+		t.Fatalf("approve cancelled: %v", err)
+	}
+	<-prompts
+
+	answer := func(line string) bool {
+		t.Helper()
+		result := make(chan bool, 1)
+		go func() {
+			approved, err := approver.Approve(context.Background(), approval)
+			if err != nil {
+				t.Errorf("approve: %v", err)
+			}
+			result <- approved
+		}()
+		<-prompts
+		if _, err := io.WriteString(terminal, line+"\n"); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return <-result
+	}
+
+	if !answer("y") {
+		t.Error("y denied")
+	}
+	if answer("n") {
+		t.Error("n approved")
+	}
+	if !answer(" Y ") {
+		t.Error("Y denied")
+	}
+
+	// Конец ввода - отказ
+	terminal.Close()
+	if approved, err := approver.Approve(context.Background(), approval); approved || err != nil {
+		t.Errorf("approve after EOF = %t, %v", approved, err)
+	}
+}
+
+// promptWriter сообщает о выведенном вопросе, после него ответ не считается лишним
+type promptWriter chan struct{}
+
+func (pw promptWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), "(y/n)") {
+		pw <- struct{}{}
+	}
+	return len(p), nil
+}
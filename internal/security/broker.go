@@ -0,0 +1,169 @@
+package security
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/gocherry/pkg/logs"
+)
+
+// ApprovalNotifier получает изменения запросов подтверждения (например, для WatchTask)
+type ApprovalNotifier interface {
+	ApprovalChanged(approval entity.Approval)
+}
+
+type pendingApproval struct {
+	approval entity.Approval
+	decision chan bool
+}
+
+// Broker хранит ожидающие подтверждения, которые разрешаются удалённо
+// через ListPendingApprovals/ResolveApproval.
+type Broker struct {
+	timeout  time.Duration
+	notifier ApprovalNotifier
+	log      *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string]*pendingApproval
+}
+
+func NewBroker(timeout time.Duration, notifier ApprovalNotifier) *Broker {
+	return &Broker{
+		timeout:  timeout,
+		notifier: notifier,
+		log:      logs.SetupLogger().With(logs.AppComponent("security.approval_broker")),
+		pending:  make(map[string]*pendingApproval),
+	}
+}
+
+func (b *Broker) Approve(ctx context.Context, approval entity.Approval) (bool, error) {
+	now := time.Now()
+	approval.ID = uuid.New().String()
+	approval.Status = entity.ApprovalStatusPending
+	approval.CreatedAt = now
+	if b.timeout > 0 {
+		approval.ExpiresAt = now.Add(b.timeout)
+	}
+
+	pa := &pendingApproval{
+		approval: approval,
+		decision: make(chan bool, 1),
+	}
+
+	b.mu.Lock()
+	b.pending[approval.ID] = pa
+	b.mu.Unlock()
+
+	b.log.Info("approval requested",
+		slog.String("id", approval.ID),
+		slog.String("task_id", approval.TaskID),
+		slog.String("kind", string(approval.Kind)),
+		slog.String("action", approval.Action),
+		slog.String("target", approval.Target),
+	)
+	b.notify(approval)
+
+	var expired <-chan time.Time
+	if b.timeout > 0 {
+		timer := time.NewTimer(b.timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case approved := <-pa.decision:
+		return approved, nil
+	case <-expired:
+		if !b.finish(approval.ID, entity.ApprovalStatusExpired) {
+			// Решение пришло одновременно с таймаутом
+			return <-pa.decision, nil
+		}
+		return false, entity.ErrApprovalExpired
+	case <-ctx.Done():
+		if !b.finish(approval.ID, entity.ApprovalStatusCancelled) {
+			return <-pa.decision, nil
+		}
+		return false, ctx.Err()
+	}
+}
+
+// ListPending возвращает ожидающие подтверждения, taskID пустой - по всем задачам
+func (b *Broker) ListPending(ctx context.Context, taskID string) []entity.Approval {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	approvals := make([]entity.Approval, 0, len(b.pending))
+	for _, pa := range b.pending {
+		if taskID == "" || pa.approval.TaskID == taskID {
+			approvals = append(approvals, pa.approval)
+		}
+	}
+
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.Before(approvals[j].CreatedAt)
+	})
+	return approvals
+}
+
+func (b *Broker) Resolve(ctx context.Context, id string, approved bool, comment string) (entity.Approval, error) {
+	status := entity.ApprovalStatusRejected
+	if approved {
+		status = entity.ApprovalStatusApproved
+	}
+
+	b.mu.Lock()
+	pa, ok := b.pending[id]
+	if !ok {
+		b.mu.Unlock()
+		return entity.Approval{}, entity.ErrApprovalNotFound
+	}
+	delete(b.pending, id)
+	pa.approval.Status = status
+	pa.approval.Comment = comment
+	pa.approval.ResolvedAt = time.Now()
+	b.mu.Unlock()
+
+	pa.decision <- approved
+
+	b.log.Info("approval resolved",
+		slog.String("id", id),
+		slog.String("status", string(status)),
+	)
+	b.notify(pa.approval)
+
+	return pa.approval, nil
+}
+
+// finish снимает подтверждение без решения.
+// Возвращает false, если оно уже разрешено через Resolve.
+func (b *Broker) finish(id string, status entity.ApprovalStatus) bool {
+	b.mu.Lock()
+	pa, ok := b.pending[id]
+	if !ok {
+		b.mu.Unlock()
+		return false
+	}
+	delete(b.pending, id)
+	pa.approval.Status = status
+	pa.approval.ResolvedAt = time.Now()
+	b.mu.Unlock()
+
+	b.log.Warn("approval finished without decision",
+		slog.String("id", id),
+		slog.String("status", string(status)),
+	)
+	b.notify(pa.approval)
+	return true
+}
+
+func (b *Broker) notify(approval entity.Approval) {
+	if b.notifier != nil {
+		b.notifier.ApprovalChanged(approval)
+	}
+}
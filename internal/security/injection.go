@@ -0,0 +1,67 @@
+package security
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// InjectionRule правило, которым помечается подтверждение шагов с подозрением на инъекцию
+const InjectionRule = "prompt-injection"
+
+type injectionPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// injectionPatterns признаки текста, обращённого к агенту, а не к человеку
+var injectionPatterns = []injectionPattern{
+	{"override", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+|the\s+|your\s+|of\s+)*(previous|prior|above|earlier|system|original)\s+(instructions|prompts?|rules|directions|messages)`)},
+	{"override", regexp.MustCompile(`(?i)(игнорируй|игнорировать|забудь|не\s+обращай\s+внимания\s+на)\s+(все\s+)?(предыдущие|прежние|системные|старые)?\s*(инструкции|указания|правила|команды)`)},
+	{"role", regexp.MustCompile(`(?i)\byou\s+are\s+now\b|\bfrom\s+now\s+on\s+you\b|\bnew\s+(instructions|task|objective)\s*:`)},
+	{"prompt_markers", regexp.MustCompile(`(?im)<\|?(im_start|im_end|system|endoftext)\|?>|\[/?(INST|SYS)\]|^\W{0,5}(###\s*)?(system|assistant)\s*:`)},
+	{"agent_directive", regexp.MustCompile(`(?i)\b(ai|llm|assistant|agent|bot|language model)s?\b[^.\n]{0,40}\b(must|should|have to|need to|are instructed to)\s+(now\s+)?(navigate|go|click|type|enter|send|open|visit|transfer|reveal)\b`)},
+	{"tool_call", regexp.MustCompile(`(?i)"action"\s*:\s*"(navigate|click|type|complete|wait_user|extract)"`)},
+	{"secrecy", regexp.MustCompile(`(?i)\b(do\s+not|don't|never)\s+(tell|inform|alert|notify|show)\s+(the\s+)?user\b`)},
+}
+
+// snippetContext символов вокруг совпадения в отчёте
+const snippetContext = 40
+
+// DetectInjection ищет в содержимом страницы текст, похожий на инструкции для агента.
+// Эвристики не заменяют разметку недоверенного содержимого в промпте, а дополняют её.
+func DetectInjection(content string) []entity.InjectionFinding {
+	var findings []entity.InjectionFinding
+
+	for _, pattern := range injectionPatterns {
+		loc := pattern.re.FindStringIndex(content)
+		if loc == nil {
+			continue
+		}
+		findings = append(findings, entity.InjectionFinding{
+			Pattern: pattern.name,
+			Snippet: snippet(content, loc[0], loc[1]),
+		})
+	}
+	return findings
+}
+
+func snippet(content string, start, end int) string {
+	from := max(0, start-snippetContext)
+	to := min(len(content), end+snippetContext)
+
+	// Не режем многобайтовые символы
+	for from > 0 && !isRuneStart(content[from]) {
+		from--
+	}
+	for to < len(content) && !isRuneStart(content[to]) {
+		to++
+	}
+
+	return strings.Join(strings.Fields(content[from:to]), " ")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
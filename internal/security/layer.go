@@ -1,46 +1,188 @@
 package security
 
 import (
-	"bufio"
-	"fmt"
-	"os"
-	"strings"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/gocherry/pkg/logs"
 )
 
 type Layer struct {
-	sensitiveActions []string
+	policy   *Policy
+	approver Approver
+	log      *slog.Logger
+
+	// injectionApproval шаги с подозрением на инъекцию всегда подтверждаются
+	injectionApproval bool
+	// vault учётные данные для входа на сайты, может отсутствовать
+	vault *Vault
+
+	// overrides скомпилированные политики выполняющихся задач, удаляются в ForgetTask
+	overrides sync.Map // task id -> *Policy
+}
+
+type LayerOption func(*Layer)
+
+// WithInjectionApproval отправляет на подтверждение шаги, на странице которых
+// найдены признаки prompt injection, даже если политика их разрешает
+func WithInjectionApproval(enabled bool) LayerOption {
+	return func(s *Layer) {
+		s.injectionApproval = enabled
+	}
+}
+
+// WithVault даёт агенту учётные данные из хранилища
+func WithVault(vault *Vault) LayerOption {
+	return func(s *Layer) {
+		s.vault = vault
+	}
+}
+
+func NewLayer(policy *Policy, approver Approver, opts ...LayerOption) *Layer {
+	if policy == nil {
+		policy = DefaultPolicy()
+	}
+	s := &Layer{
+		policy:   policy,
+		approver: approver,
+		log:      logs.SetupLogger().With(logs.AppComponent("security.layer")),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SecretRefs плейсхолдеры учётных данных, доступных модели
+func (s *Layer) SecretRefs() []entity.SecretRef {
+	if s.vault == nil {
+		return nil
+	}
+	return s.vault.Refs()
 }
 
-func NewLayer() *Layer {
-	return &Layer{
-		sensitiveActions: []string{
-			"buy", "purchase", "pay", "order", "checkout",
-			"delete", "remove", "cancel", "unsubscribe",
-			"confirm", "submit", "send", "post", "publish",
-			"transfer", "withdraw", "install", "download",
-			"auth",
-		},
+// Redact скрывает значения секретов в тексте для модели, логов и журнала шагов
+func (s *Layer) Redact(text string) string {
+	if s.vault == nil {
+		return text
 	}
+	return s.vault.Redact(text)
 }
 
-func (s *Layer) CheckAction(action, target, reasoning string) bool {
-	actionLower := strings.ToLower(action + " " + target + " " + reasoning)
+// ScanContent ищет в содержимом страницы признаки prompt injection
+func (s *Layer) ScanContent(content string) []entity.InjectionFinding {
+	return DetectInjection(content)
+}
+
+// CheckAction проверяет шаг по политике задачи и при необходимости запрашивает подтверждение
+func (s *Layer) CheckAction(ctx context.Context, task entity.PoolTask, step entity.TaskStep) (entity.ActionVerdict, error) {
+	policy, err := s.policyFor(task)
+	if err != nil {
+		return entity.ActionVerdict{}, err
+	}
 
-	for _, sensitive := range s.sensitiveActions {
-		if strings.Contains(actionLower, sensitive) {
-			fmt.Printf("\n🚨 SECURITY ALERT 🚨\n")
-			fmt.Printf("Action: %s %s\n", action, target)
-			fmt.Printf("Reasoning: %s\n", reasoning)
-			fmt.Printf("This appears to be a sensitive action.\n")
-			fmt.Print("Do you want to proceed? (y/n): ")
+	verdict := policy.Evaluate(step)
+	s.applyModelRequest(&verdict, step)
 
-			scanner := bufio.NewScanner(os.Stdin)
-			if scanner.Scan() {
-				response := strings.TrimSpace(scanner.Text())
-				return strings.ToLower(response) == "y"
-			}
+	if verdict.Disagreement != "" {
+		s.log.Info("model and policy disagree",
+			slog.String("task_id", task.ID),
+			slog.String("disagreement", string(verdict.Disagreement)),
+			slog.String("rule", verdict.Rule),
+			slog.String("action", step.Action.Action),
+			slog.String("target", step.Action.Target),
+			slog.String("page_url", step.PageURL),
+		)
+	}
+
+	if s.injectionApproval && len(step.Injection) > 0 && verdict.Outcome == entity.PolicyOutcomeAllow {
+		verdict.Outcome = entity.PolicyOutcomeRequireApproval
+		verdict.Rule = InjectionRule
+	}
+
+	switch verdict.Outcome {
+	case entity.PolicyOutcomeAllow:
+		verdict.Approved = true
+	case entity.PolicyOutcomeRequireApproval:
+		approval := newApproval(entity.ApprovalKindAction, task.ID, step)
+		approval.Rule = verdict.Rule
+		approval.ModelRequested = verdict.ModelRequested
+
+		// Истёкшее ожидание - отказ, а не сбой проверки, задачу прерывает только отмена ctx
+		verdict.Approved, err = s.approver.Approve(ctx, approval)
+		if errors.Is(err, entity.ErrApprovalExpired) {
+			s.log.Warn("approval expired", slog.String("task_id", task.ID), slog.String("rule", verdict.Rule))
+			verdict.Reason = err.Error()
+			err = nil
+		}
+		if err != nil {
+			return verdict, err
 		}
 	}
 
-	return true
+	return verdict, nil
+}
+
+// applyModelRequest учитывает need_approval модели как отдельный повод для подтверждения:
+// разрешённое политикой действие всё равно подтверждается, запрет остаётся запретом
+func (s *Layer) applyModelRequest(verdict *entity.ActionVerdict, step entity.TaskStep) {
+	verdict.ModelRequested = step.Action.NeedApproval
+	policySensitive := verdict.Outcome != entity.PolicyOutcomeAllow
+
+	switch {
+	case verdict.ModelRequested && !policySensitive:
+		verdict.Disagreement = entity.PolicyDisagreementModelOnly
+		verdict.Outcome = entity.PolicyOutcomeRequireApproval
+	case !verdict.ModelRequested && policySensitive:
+		verdict.Disagreement = entity.PolicyDisagreementPolicyOnly
+	}
+}
+
+// policyFor общая политика с правилами задачи поверх неё
+func (s *Layer) policyFor(task entity.PoolTask) (*Policy, error) {
+	if task.Options.Policy == "" {
+		return s.policy, nil
+	}
+
+	if policy, ok := s.overrides.Load(task.ID); ok {
+		return policy.(*Policy), nil
+	}
+
+	taskPolicy, err := ParsePolicy([]byte(task.Options.Policy))
+	if err != nil {
+		return nil, err
+	}
+
+	policy, _ := s.overrides.LoadOrStore(task.ID, s.policy.With(taskPolicy))
+	return policy.(*Policy), nil
+}
+
+// ForgetTask удаляет политику завершённой задачи
+func (s *Layer) ForgetTask(taskID string) {
+	s.overrides.Delete(taskID)
+}
+
+// RequestUserAction ждёт, пока пользователь выполнит действия в браузере (wait_user).
+// Истёкшее ожидание - отказ, как и в CheckAction
+func (s *Layer) RequestUserAction(ctx context.Context, taskID string, step entity.TaskStep) (bool, error) {
+	approved, err := s.approver.Approve(ctx, newApproval(entity.ApprovalKindUserAction, taskID, step))
+	if errors.Is(err, entity.ErrApprovalExpired) {
+		s.log.Warn("user action expired", slog.String("task_id", taskID))
+		return false, nil
+	}
+	return approved, err
+}
+
+func newApproval(kind entity.ApprovalKind, taskID string, step entity.TaskStep) entity.Approval {
+	return entity.Approval{
+		TaskID:    taskID,
+		Kind:      kind,
+		Action:    step.Action.Action,
+		Target:    step.Action.Target,
+		Reasoning: step.Action.Reasoning,
+		PageURL:   step.PageURL,
+	}
 }
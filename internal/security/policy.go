@@ -0,0 +1,313 @@
+package security
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_policy.yaml
+var defaultPolicy []byte
+
+// Типы полей форм, которые различает политика
+const (
+	FieldPassword   = "password"
+	FieldCreditCard = "credit_card"
+	FieldEmail      = "email"
+	FieldPhone      = "phone"
+)
+
+var fieldTypes = []string{FieldPassword, FieldCreditCard, FieldEmail, FieldPhone}
+
+// Policy правила безопасности. Решение даёт первое совпавшее правило,
+// если не совпало ни одно - Default.
+type Policy struct {
+	Default entity.PolicyOutcome `yaml:"default"`
+	Rules   []Rule               `yaml:"rules"`
+}
+
+type Rule struct {
+	Name    string               `yaml:"name"`
+	Match   Match                `yaml:"match"`
+	Outcome entity.PolicyOutcome `yaml:"outcome"`
+
+	match matcher
+}
+
+// Match условия правила, все заданные условия должны выполниться.
+// Регулярные выражения не чувствительны к регистру.
+type Match struct {
+	// Actions типы действий: click, type, navigate...
+	Actions []string `yaml:"actions"`
+	// Target регулярное выражение по описанию цели и тексту элемента
+	Target string `yaml:"target"`
+	// Selector регулярное выражение по описанию элемента вида button#pay.btn[name=submit]
+	Selector string `yaml:"selector"`
+	// URL регулярное выражение по адресу страницы, для navigate - по адресу перехода
+	URL string `yaml:"url"`
+	// Domains домены страницы или перехода, *.example.com включает поддомены
+	Domains []string `yaml:"domains"`
+	// FieldTypes типы поля, а для кнопок - полей их формы: password, credit_card, email, phone
+	FieldTypes []string `yaml:"field_types"`
+	// NeedApproval флаг need_approval из ответа модели
+	NeedApproval *bool `yaml:"need_approval"`
+	// InjectionSuspected на странице найден текст, похожий на инструкции для агента
+	InjectionSuspected *bool `yaml:"injection_suspected"`
+}
+
+type matcher struct {
+	target   *regexp.Regexp
+	selector *regexp.Regexp
+	url      *regexp.Regexp
+}
+
+// DefaultPolicy встроенная политика
+func DefaultPolicy() *Policy {
+	policy, err := ParsePolicy(defaultPolicy)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default policy: %v", err))
+	}
+	return policy
+}
+
+// LoadPolicy читает политику из YAML файла, пустой путь - встроенная политика
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %v", err)
+	}
+	return ParsePolicy(data)
+}
+
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy: %v", err)
+	}
+
+	if err := policy.compile(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p *Policy) compile() error {
+	if p.Default != "" && !p.Default.IsValid() {
+		return fmt.Errorf("invalid default outcome: %q", p.Default)
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return fmt.Errorf("policy rule %s: %v", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if !r.Outcome.IsValid() {
+		return fmt.Errorf("invalid outcome: %q", r.Outcome)
+	}
+
+	for _, fieldType := range r.Match.FieldTypes {
+		if !slices.Contains(fieldTypes, fieldType) {
+			return fmt.Errorf("unknown field type: %q", fieldType)
+		}
+	}
+
+	var err error
+	for _, re := range []struct {
+		expr   string
+		target **regexp.Regexp
+	}{
+		{r.Match.Target, &r.match.target},
+		{r.Match.Selector, &r.match.selector},
+		{r.Match.URL, &r.match.url},
+	} {
+		if re.expr == "" {
+			continue
+		}
+		if *re.target, err = regexp.Compile("(?i)" + re.expr); err != nil {
+			return fmt.Errorf("invalid regexp %q: %v", re.expr, err)
+		}
+	}
+	return nil
+}
+
+// With возвращает политику, в которой правила override проверяются раньше правил p.
+// Default берётся из override, если задан.
+func (p *Policy) With(override *Policy) *Policy {
+	if override == nil {
+		return p
+	}
+
+	merged := &Policy{
+		Default: p.Default,
+		Rules:   slices.Concat(override.Rules, p.Rules),
+	}
+	if override.Default != "" {
+		merged.Default = override.Default
+	}
+	return merged
+}
+
+// Evaluate находит решение по шагу задачи
+func (p *Policy) Evaluate(step entity.TaskStep) entity.ActionVerdict {
+	for _, rule := range p.Rules {
+		if rule.matches(step) {
+			return entity.ActionVerdict{Outcome: rule.Outcome, Rule: rule.Name}
+		}
+	}
+
+	outcome := p.Default
+	if outcome == "" {
+		outcome = entity.PolicyOutcomeAllow
+	}
+	return entity.ActionVerdict{Outcome: outcome}
+}
+
+func (r *Rule) matches(step entity.TaskStep) bool {
+	action := step.Action
+	element := step.Element
+
+	if len(r.Match.Actions) > 0 && !slices.Contains(r.Match.Actions, action.Action) {
+		return false
+	}
+
+	if r.Match.NeedApproval != nil && *r.Match.NeedApproval != action.NeedApproval {
+		return false
+	}
+
+	if r.Match.InjectionSuspected != nil && *r.Match.InjectionSuspected != (len(step.Injection) > 0) {
+		return false
+	}
+
+	if r.match.target != nil {
+		texts := []string{action.Target}
+		if element != nil {
+			texts = append(texts, element.Text)
+		}
+		if !slices.ContainsFunc(texts, r.match.target.MatchString) {
+			return false
+		}
+	}
+
+	if r.match.selector != nil && (element == nil || !r.match.selector.MatchString(element.Selector)) {
+		return false
+	}
+
+	pageURL := actionURL(step)
+	if r.match.url != nil && !r.match.url.MatchString(pageURL) {
+		return false
+	}
+
+	if len(r.Match.Domains) > 0 && !matchDomains(r.Match.Domains, pageURL) {
+		return false
+	}
+
+	if len(r.Match.FieldTypes) > 0 && !matchFieldTypes(r.Match.FieldTypes, element, action.Action == "press_key") {
+		return false
+	}
+
+	return true
+}
+
+// actionURL адрес, к которому относится действие: для navigate - адрес перехода
+func actionURL(step entity.TaskStep) string {
+	if step.Action.Action != "navigate" {
+		return step.PageURL
+	}
+
+	target := step.Action.URL
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+	return target
+}
+
+func matchDomains(domains []string, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	return slices.ContainsFunc(domains, func(domain string) bool {
+		return entity.MatchDomain(domain, u.Hostname())
+	})
+}
+
+// matchFieldTypes submits - действие может отправить форму поля, например Enter
+func matchFieldTypes(types []string, element *entity.Element, submits bool) bool {
+	if element == nil {
+		return false
+	}
+
+	// Для полей проверяется само поле, для кнопок и отправки с поля - поля их формы
+	fields := element.Form
+	if isFormField(element) {
+		fields = []entity.FormField{element.FormField}
+		if submits {
+			fields = append(fields, element.Form...)
+		}
+	}
+	for _, field := range fields {
+		if slices.Contains(types, fieldType(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFormField(element *entity.Element) bool {
+	switch element.Tag {
+	case "textarea", "select":
+		return true
+	case "input":
+		switch element.Type {
+		case "submit", "button", "reset", "image":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+var cardFieldName = regexp.MustCompile(`(?i)card.?(num|no)|cc.?(num|no)|cvv|cvc|csc|exp(iry|iration)?.?(date|month|year)`)
+
+// fieldType тип данных поля по его type, autocomplete и name
+func fieldType(field entity.FormField) string {
+	autocomplete := strings.ToLower(field.Autocomplete)
+
+	switch {
+	case field.Type == "password",
+		autocomplete == "current-password", autocomplete == "new-password":
+		return FieldPassword
+	case strings.HasPrefix(autocomplete, "cc-"), cardFieldName.MatchString(field.Name):
+		return FieldCreditCard
+	case field.Type == "email", autocomplete == "email":
+		return FieldEmail
+	case field.Type == "tel", strings.HasPrefix(autocomplete, "tel"):
+		return FieldPhone
+	}
+	return ""
+}
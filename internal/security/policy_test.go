@@ -0,0 +1,182 @@
+package security_test
+
+import (
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	t.Parallel()
+
+	policy, err := security.ParsePolicy([]byte(`
+default: allow
+rules:
+  - name: no-bank-navigation
+    match: { actions: [navigate], domains: ["*.bank.example"] }
+    outcome: deny
+  - name: login-submit
+    match: { actions: [click], field_types: [password], url: '/login' }
+    outcome: require_approval
+  - name: delete-buttons
+    match: { actions: [click], selector: '\.danger' }
+    outcome: require_approval
+  - name: model-requested
+    match: { need_approval: true }
+    outcome: require_approval
+`))
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	merged := security.DefaultPolicy().With(policy)
+
+	loginForm := []entity.FormField{{Type: "text", Name: "username"}, {Type: "password", Name: "password"}}
+	cardForm := []entity.FormField{{Type: "text", Name: "holder"}, {Type: "text", Autocomplete: "cc-number"}}
+
+	tests := []struct {
+		name    string
+		policy  *security.Policy
+		step    entity.TaskStep
+		outcome entity.PolicyOutcome
+		rule    string
+	}{
+		{
+			name:    "reasoning words are ignored",
+			policy:  security.DefaultPolicy(),
+			step:    step("click", "Next", "send the form later", "https://shop.example/"),
+			outcome: entity.PolicyOutcomeAllow,
+		},
+		{
+			name:    "russian pay button",
+			policy:  security.DefaultPolicy(),
+			step:    step("click", "Оплатить", "finish", "https://shop.example/"),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "irreversible-click",
+		},
+		{
+			name:    "enter on a focused pay button",
+			policy:  security.DefaultPolicy(),
+			step:    withElement(step("press_key", "", "", "https://shop.example/"), &entity.Element{Tag: "button", Text: "Оплатить", FormField: entity.FormField{Type: "submit"}}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "irreversible-click",
+		},
+		{
+			name:    "enter submits a card form",
+			policy:  security.DefaultPolicy(),
+			step:    withElement(step("press_key", "", "", "https://shop.example/"), &entity.Element{Tag: "input", FormField: entity.FormField{Type: "text", Name: "holder"}, Form: cardForm}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "payment-details",
+		},
+		{
+			name:    "typing into a card form outside card fields",
+			policy:  security.DefaultPolicy(),
+			step:    withElement(step("type", "holder", "", "https://shop.example/"), &entity.Element{Tag: "input", FormField: entity.FormField{Type: "text", Name: "holder"}, Form: cardForm}),
+			outcome: entity.PolicyOutcomeAllow,
+		},
+		{
+			name:   "card field by autocomplete",
+			policy: security.DefaultPolicy(),
+			step: withElement(step("type", "number", "", "https://shop.example/"), &entity.Element{
+				Tag:       "input",
+				FormField: entity.FormField{Type: "text", Autocomplete: "cc-number"},
+			}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "payment-details",
+		},
+		{
+			name:    "navigate checks destination domain",
+			policy:  policy,
+			step:    withURL(step("navigate", "", "", "https://shop.example/"), "online.bank.example/transfer"),
+			outcome: entity.PolicyOutcomeDeny,
+			rule:    "no-bank-navigation",
+		},
+		{
+			name:    "page domain does not match navigation away",
+			policy:  policy,
+			step:    withURL(step("navigate", "", "", "https://online.bank.example/"), "https://shop.example/"),
+			outcome: entity.PolicyOutcomeAllow,
+		},
+		{
+			name:    "submit button of a password form",
+			policy:  policy,
+			step:    withElement(step("click", "Sign in", "", "https://shop.example/login"), &entity.Element{Tag: "button", FormField: entity.FormField{Type: "submit"}, Form: loginForm}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "login-submit",
+		},
+		{
+			name:    "text field of a password form",
+			policy:  policy,
+			step:    withElement(step("click", "username", "", "https://shop.example/login"), &entity.Element{Tag: "input", FormField: entity.FormField{Type: "text"}, Form: loginForm}),
+			outcome: entity.PolicyOutcomeAllow,
+		},
+		{
+			name:    "selector",
+			policy:  policy,
+			step:    withElement(step("click", "Remove", "", "https://shop.example/"), &entity.Element{Tag: "button", Selector: "button#rm.btn.danger"}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "delete-buttons",
+		},
+		{
+			name:    "need approval flag",
+			policy:  policy,
+			step:    withNeedApproval(step("type", "comment", "", "https://shop.example/")),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "model-requested",
+		},
+		{
+			name:    "task rules go first",
+			policy:  merged,
+			step:    withElement(step("click", "Удалить", "", "https://shop.example/"), &entity.Element{Tag: "button", Selector: "button.danger"}),
+			outcome: entity.PolicyOutcomeRequireApproval,
+			rule:    "delete-buttons",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := tt.policy.Evaluate(tt.step)
+			if verdict.Outcome != tt.outcome || verdict.Rule != tt.rule {
+				t.Errorf("verdict = %s (%q), want %s (%q)", verdict.Outcome, verdict.Rule, tt.outcome, tt.rule)
+			}
+		})
+	}
+}
+
+func TestPolicyParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for name, policy := range map[string]string{
+		"unknown outcome":    "rules: [{match: {actions: [click]}, outcome: maybe}]",
+		"unknown field type": "rules: [{match: {field_types: [ssn]}, outcome: deny}]",
+		"bad regexp":         "rules: [{match: {target: '('}, outcome: deny}]",
+		"unknown key":        "rules: [{match: {action: click}, outcome: deny}]",
+		"bad default":        "default: block",
+	} {
+		if _, err := security.ParsePolicy([]byte(policy)); err == nil {
+			t.Errorf("%s: policy accepted", name)
+		}
+	}
+}
+
+func step(action, target, reasoning, pageURL string) entity.TaskStep {
+	return entity.TaskStep{
+		PageURL: pageURL,
+		Action:  entity.AiResponse{Action: action, Target: target, Reasoning: reasoning},
+	}
+}
+
+func withElement(s entity.TaskStep, element *entity.Element) entity.TaskStep {
+	s.Element = element
+	return s
+}
+
+func withURL(s entity.TaskStep, url string) entity.TaskStep {
+	s.Action.URL = url
+	return s
+}
+
+func withNeedApproval(s entity.TaskStep) entity.TaskStep {
+	s.Action.NeedApproval = true
+	return s
+}
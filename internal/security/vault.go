@@ -0,0 +1,322 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// secretPlaceholder {{secret:<credential>.<field>}}
+var secretPlaceholder = regexp.MustCompile(`\{\{secret:([A-Za-z0-9_-]+)\.([A-Za-z0-9_-]+)\}\}`)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+const (
+	vaultKeyIterations = 600_000
+	vaultKeyLength     = 32
+)
+
+// Credential учётные данные одного сайта
+type Credential struct {
+	// Domains сайты, на которых можно вводить поля, *.example.com - с поддоменами
+	Domains []string `json:"domains"`
+	// Fields например username и password
+	Fields map[string]string `json:"fields"`
+}
+
+// Vault локальное хранилище учётных данных, зашифрованное AES-GCM
+// ключом из пароля VAULT_KEY. Модель видит только плейсхолдеры,
+// значения подставляются при вводе текста на странице.
+type Vault struct {
+	path string
+	key  []byte
+	salt []byte
+
+	mu          sync.RWMutex
+	credentials map[string]Credential
+}
+
+// vaultFile содержимое файла хранилища
+type vaultFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// OpenVault открывает хранилище, отсутствующий файл - пустое хранилище
+func OpenVault(path, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault key is empty")
+	}
+
+	v := &Vault{
+		path:        path,
+		credentials: make(map[string]Credential),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		v.salt = make([]byte, 16)
+		if _, err := rand.Read(v.salt); err != nil {
+			return nil, fmt.Errorf("failed to init vault: %v", err)
+		}
+		if v.key, err = deriveVaultKey(passphrase, v.salt); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %v", err)
+	}
+
+	var file vaultFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %v", err)
+	}
+
+	v.salt = file.Salt
+	if v.key, err = deriveVaultKey(passphrase, v.salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(v.key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt vault: wrong key or corrupted file")
+	}
+	if err := json.Unmarshal(plain, &v.credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse vault: %v", err)
+	}
+
+	return v, nil
+}
+
+func deriveVaultKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, vaultKeyIterations, vaultKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive vault key: %v", err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init vault cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Set сохраняет поле учётных данных и привязывает их к доменам
+func (v *Vault) Set(name, field, value string, domains []string) error {
+	if !validName.MatchString(name) || !validName.MatchString(field) {
+		return fmt.Errorf("invalid secret name %s.%s", name, field)
+	}
+
+	rules := entity.DomainRules{Allowed: domains}
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	credential := v.credentials[name]
+	if len(domains) > 0 {
+		credential.Domains = domains
+	}
+	if len(credential.Domains) == 0 {
+		return fmt.Errorf("secret %s is not bound to any domain", name)
+	}
+	if credential.Fields == nil {
+		credential.Fields = make(map[string]string)
+	}
+	credential.Fields[field] = value
+	v.credentials[name] = credential
+
+	return v.save()
+}
+
+// Delete удаляет учётные данные целиком
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.credentials[name]; !ok {
+		return entity.ErrSecretNotFound
+	}
+	delete(v.credentials, name)
+	return v.save()
+}
+
+// save шифрует хранилище и атомарно перезаписывает файл, вызывается под v.mu
+func (v *Vault) save() error {
+	plain, err := json.Marshal(v.credentials)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %v", err)
+	}
+
+	aead, err := newAEAD(v.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to encrypt vault: %v", err)
+	}
+
+	data, err := json.Marshal(vaultFile{
+		Salt:  v.salt,
+		Nonce: nonce,
+		Data:  aead.Seal(nil, nonce, plain, nil),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	if err := os.Rename(tmp, v.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write vault: %v", err)
+	}
+	return nil
+}
+
+// Refs плейсхолдеры всех секретов, значения не раскрываются
+func (v *Vault) Refs() []entity.SecretRef {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var refs []entity.SecretRef
+	for name, credential := range v.credentials {
+		for field := range credential.Fields {
+			refs = append(refs, entity.SecretRef{
+				Placeholder: placeholder(name, field),
+				Domains:     slices.Clone(credential.Domains),
+			})
+		}
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].Placeholder < refs[j].Placeholder
+	})
+	return refs
+}
+
+func placeholder(name, field string) string {
+	return fmt.Sprintf("{{secret:%s.%s}}", name, field)
+}
+
+// Reveal подставляет значения секретов в text для ввода на странице pageURL.
+// Секрет вводится только на своих доменах и только по https (кроме localhost).
+func (v *Vault) Reveal(text, pageURL string) (string, error) {
+	if !strings.Contains(text, "{{secret:") {
+		return text, nil
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var revealErr error
+	revealed := secretPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		parts := secretPlaceholder.FindStringSubmatch(match)
+		name, field := parts[1], parts[2]
+
+		credential, ok := v.credentials[name]
+		value, found := credential.Fields[field]
+		if !ok || !found {
+			revealErr = fmt.Errorf("%s: %w", match, entity.ErrSecretNotFound)
+			return match
+		}
+
+		if err := checkSecretURL(credential.Domains, pageURL); err != nil {
+			revealErr = fmt.Errorf("%s %v: %w", match, err, entity.ErrSecretNotAllowed)
+			return match
+		}
+		return value
+	})
+
+	if revealErr != nil {
+		return "", revealErr
+	}
+	return revealed, nil
+}
+
+func checkSecretURL(domains []string, pageURL string) error {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("on page %q", pageURL)
+	}
+
+	host := u.Hostname()
+	if u.Scheme != "https" && !isLoopback(host) {
+		return fmt.Errorf("over %s", u.Scheme)
+	}
+	if !slices.ContainsFunc(domains, func(domain string) bool {
+		return entity.MatchDomain(domain, host)
+	}) {
+		return fmt.Errorf("on %s", host)
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Redact заменяет значения секретов в тексте их плейсхолдерами
+func (v *Vault) Redact(text string) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	type secret struct{ value, placeholder string }
+	var secrets []secret
+	for name, credential := range v.credentials {
+		for field, value := range credential.Fields {
+			// Слишком короткие значения дадут ложные замены в обычном тексте
+			if len(value) >= 4 {
+				secrets = append(secrets, secret{value, placeholder(name, field)})
+			}
+		}
+	}
+
+	// Длинные значения первыми, если одно содержит другое.
+	// Замена за один проход, чтобы не задеть уже вставленные плейсхолдеры
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i].value) > len(secrets[j].value)
+	})
+	pairs := make([]string, 0, len(secrets)*2)
+	for _, s := range secrets {
+		pairs = append(pairs, s.value, s.placeholder)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
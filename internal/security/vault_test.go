@@ -0,0 +1,81 @@
+package security_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/security"
+)
+
+func TestVault(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "vault.json")
+
+	vault, err := security.OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatalf("open vault: %v", err)
+	}
+	if err := vault.Set("github", "password", "hunter2-long", []string{"github.com", "*.github.com"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := vault.Set("github", "token", "hunter2-long-token", nil); err != nil {
+		t.Fatalf("set without domains keeps the binding: %v", err)
+	}
+	if err := vault.Set("mail", "password", "secret", []string{"mail.example"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := vault.Set("unbound", "password", "x", nil); err == nil {
+		t.Error("secret without domains accepted")
+	}
+
+	// Значения хранятся только в зашифрованном виде
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read vault: %v", err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Error("vault file contains the secret in plain text")
+	}
+
+	if _, err := security.OpenVault(path, "wrong horse"); err == nil {
+		t.Error("vault opened with a wrong key")
+	}
+
+	vault, err = security.OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatalf("reopen vault: %v", err)
+	}
+
+	revealed, err := vault.Reveal("pass: {{secret:github.password}}", "https://gist.github.com/login")
+	if err != nil || revealed != "pass: hunter2-long" {
+		t.Errorf("reveal = %q, %v", revealed, err)
+	}
+
+	for url, want := range map[string]error{
+		"https://github.com.evil.test/login": entity.ErrSecretNotAllowed,
+		"http://github.com/login":            entity.ErrSecretNotAllowed,
+		"about:blank":                        entity.ErrSecretNotAllowed,
+	} {
+		if _, err := vault.Reveal("{{secret:github.password}}", url); !errors.Is(err, want) {
+			t.Errorf("%s: err = %v, want %v", url, err, want)
+		}
+	}
+	if _, err := vault.Reveal("{{secret:gitlab.password}}", "https://github.com/"); !errors.Is(err, entity.ErrSecretNotFound) {
+		t.Errorf("unknown secret: err = %v", err)
+	}
+
+	// Значение "secret" не должно задеть уже вставленные плейсхолдеры
+	redacted := vault.Redact(`textbox "Token": hunter2-long-token, textbox "Password": hunter2-long, secret`)
+	if want := `textbox "Token": {{secret:github.token}}, textbox "Password": {{secret:github.password}}, {{secret:mail.password}}`; redacted != want {
+		t.Errorf("redact = %q, want %q", redacted, want)
+	}
+
+	if refs := vault.Refs(); len(refs) != 3 || refs[0].Placeholder != "{{secret:github.password}}" {
+		t.Errorf("refs = %+v", refs)
+	}
+}
@@ -0,0 +1,220 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+type Config struct {
+	// Dir каталог с данными агента
+	Dir string `env:"STORE_DIR" env-default:"data"`
+}
+
+func (conf Config) Validate() error {
+	if conf.Dir == "" {
+		return fmt.Errorf("store dir is empty")
+	}
+	return nil
+}
+
+// FileStore хранит каждую задачу с её шагами в отдельном JSON файле.
+// Файлы перезаписываются атомарно, поэтому падение процесса не оставляет
+// задачу в полузаписанном состоянии. Значения заголовков и пароль прокси
+// задачи на диск не попадают.
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// taskFile содержимое файла задачи
+type taskFile struct {
+	Task  entity.Task       `json:"task"`
+	Steps []entity.TaskStep `json:"steps"`
+}
+
+func NewFileStore(conf Config) (*FileStore, error) {
+	dir := filepath.Join(conf.Dir, "tasks")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create store dir: %v", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (fs *FileStore) CreateTask(ctx context.Context, task entity.Task) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	path, err := fs.path(task.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("task %s already exists", task.ID)
+	}
+	return fs.write(path, taskFile{Task: redacted(task)})
+}
+
+func (fs *FileStore) UpdateTask(ctx context.Context, task entity.Task) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.modify(task.ID, func(file *taskFile) {
+		file.Task = redacted(task)
+	})
+}
+
+// redacted задача без секретов настроек браузера
+func redacted(task entity.Task) entity.Task {
+	task.Options.Browser = task.Options.Browser.Redacted()
+	return task
+}
+
+func (fs *FileStore) AppendStep(ctx context.Context, taskID string, step entity.TaskStep) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.modify(taskID, func(file *taskFile) {
+		file.Steps = append(file.Steps, step)
+		file.Task.Steps = step.Number
+		file.Task.LastAction = &step.Action
+	})
+}
+
+func (fs *FileStore) GetTask(ctx context.Context, id string) (entity.Task, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	file, err := fs.read(id)
+	if err != nil {
+		return entity.Task{}, err
+	}
+	return file.Task, nil
+}
+
+func (fs *FileStore) ListSteps(ctx context.Context, taskID string) ([]entity.TaskStep, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	file, err := fs.read(taskID)
+	if err != nil {
+		return nil, err
+	}
+	return file.Steps, nil
+}
+
+// ListTasks возвращает задачи с указанными статусами (все, если статусы не заданы)
+// в порядке создания
+func (fs *FileStore) ListTasks(ctx context.Context, statuses ...entity.TaskStatus) ([]entity.Task, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %v", err)
+	}
+
+	var tasks []entity.Task
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		file, err := fs.read(id)
+		if err != nil {
+			return nil, err
+		}
+		if len(statuses) > 0 && !slices.Contains(statuses, file.Task.Status) {
+			continue
+		}
+		tasks = append(tasks, file.Task)
+	}
+
+	slices.SortFunc(tasks, func(a, b entity.Task) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return tasks, nil
+}
+
+func (fs *FileStore) modify(id string, fn func(file *taskFile)) error {
+	file, err := fs.read(id)
+	if err != nil {
+		return err
+	}
+	fn(&file)
+
+	path, err := fs.path(id)
+	if err != nil {
+		return err
+	}
+	return fs.write(path, file)
+}
+
+// path проверяет id, чтобы он не выходил за пределы каталога
+func (fs *FileStore) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || strings.HasPrefix(id, ".") {
+		return "", entity.ErrTaskNotFound
+	}
+	return filepath.Join(fs.dir, id+".json"), nil
+}
+
+func (fs *FileStore) read(id string) (taskFile, error) {
+	path, err := fs.path(id)
+	if err != nil {
+		return taskFile{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return taskFile{}, entity.ErrTaskNotFound
+	}
+	if err != nil {
+		return taskFile{}, fmt.Errorf("failed to read task %s: %v", id, err)
+	}
+
+	var file taskFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return taskFile{}, fmt.Errorf("failed to parse task %s: %v", id, err)
+	}
+	return file, nil
+}
+
+// write пишет во временный файл и переименовывает его поверх старого
+func (fs *FileStore) write(path string, file taskFile) error {
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal task: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(fs.dir, ".task-*")
+	if err != nil {
+		return fmt.Errorf("failed to write task: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write task: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write task: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write task: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write task: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package local
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// ProfileStore хранит storage state браузера (формат playwright: cookies и origins)
+// по одному JSON файлу на профиль. Файлы содержат сессии, поэтому доступны только владельцу.
+type ProfileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// storageState поля storage state, нужные для проверки и сводки
+type storageState struct {
+	Cookies []json.RawMessage `json:"cookies"`
+	Origins []json.RawMessage `json:"origins"`
+}
+
+func NewProfileStore(conf Config) (*ProfileStore, error) {
+	dir := filepath.Join(conf.Dir, "profiles")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create profiles dir: %v", err)
+	}
+	return &ProfileStore{dir: dir}, nil
+}
+
+// ListProfiles возвращает профили по имени
+func (ps *ProfileStore) ListProfiles(ctx context.Context) ([]entity.Profile, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	entries, err := os.ReadDir(ps.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %v", err)
+	}
+
+	var profiles []entity.Profile
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || entity.ValidateProfileName(name) != nil {
+			continue
+		}
+
+		profile, _, err := ps.read(name)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	slices.SortFunc(profiles, func(a, b entity.Profile) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return profiles, nil
+}
+
+// LoadProfile возвращает storage state профиля
+func (ps *ProfileStore) LoadProfile(ctx context.Context, name string) (entity.Profile, json.RawMessage, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.read(name)
+}
+
+// SaveProfile проверяет и атомарно записывает storage state профиля
+func (ps *ProfileStore) SaveProfile(ctx context.Context, name string, state json.RawMessage) (entity.Profile, error) {
+	if err := validateState(name, state); err != nil {
+		return entity.Profile{}, err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.save(name, state)
+}
+
+// UpdateProfile записывает storage state, только если профиль не менялся с загрузки версии version.
+// Пустая version - профиля не было. Удалённый или перезаписанный профиль не восстанавливается
+// и не затирается: возвращается entity.ErrProfileChanged.
+func (ps *ProfileStore) UpdateProfile(ctx context.Context, name string, state json.RawMessage, version string) (entity.Profile, error) {
+	if err := validateState(name, state); err != nil {
+		return entity.Profile{}, err
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	current, _, err := ps.read(name)
+	if err != nil && !errors.Is(err, entity.ErrProfileNotFound) {
+		return entity.Profile{}, err
+	}
+	if current.Version != version {
+		return entity.Profile{}, entity.ErrProfileChanged
+	}
+	return ps.save(name, state)
+}
+
+func validateState(name string, state json.RawMessage) error {
+	if err := entity.ValidateProfileName(name); err != nil {
+		return err
+	}
+	var parsed storageState
+	if err := json.Unmarshal(state, &parsed); err != nil {
+		return fmt.Errorf("%w: %v", entity.ErrInvalidStorageState, err)
+	}
+	return nil
+}
+
+func (ps *ProfileStore) save(name string, state json.RawMessage) (entity.Profile, error) {
+	if err := ps.write(name, state); err != nil {
+		return entity.Profile{}, err
+	}
+	profile, _, err := ps.read(name)
+	return profile, err
+}
+
+// DeleteProfile удаляет профиль вместе с сессиями
+func (ps *ProfileStore) DeleteProfile(ctx context.Context, name string) error {
+	if err := entity.ValidateProfileName(name); err != nil {
+		return entity.ErrProfileNotFound
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	err := os.Remove(ps.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return entity.ErrProfileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete profile %s: %v", name, err)
+	}
+	return nil
+}
+
+func (ps *ProfileStore) path(name string) string {
+	return filepath.Join(ps.dir, name+".json")
+}
+
+func (ps *ProfileStore) read(name string) (entity.Profile, json.RawMessage, error) {
+	if err := entity.ValidateProfileName(name); err != nil {
+		return entity.Profile{}, nil, entity.ErrProfileNotFound
+	}
+
+	path := ps.path(name)
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entity.Profile{}, nil, entity.ErrProfileNotFound
+	}
+	if err != nil {
+		return entity.Profile{}, nil, fmt.Errorf("failed to read profile %s: %v", name, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entity.Profile{}, nil, fmt.Errorf("failed to read profile %s: %v", name, err)
+	}
+
+	var state storageState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return entity.Profile{}, nil, fmt.Errorf("failed to parse profile %s: %v", name, err)
+	}
+
+	return entity.Profile{
+		Name:      name,
+		Cookies:   len(state.Cookies),
+		Origins:   len(state.Origins),
+		UpdatedAt: info.ModTime(),
+		Version:   version(data),
+	}, data, nil
+}
+
+// version хеш содержимого профиля: время изменения файла может совпасть у двух записей
+func version(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// write пишет во временный файл и переименовывает его поверх старого
+func (ps *ProfileStore) write(name string, state json.RawMessage) error {
+	tmp, err := os.CreateTemp(ps.dir, ".profile-*")
+	if err != nil {
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(state); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), ps.path(name)); err != nil {
+		return fmt.Errorf("failed to write profile: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package local_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/ai-cherry-bro/internal/store/local"
+)
+
+func TestProfileStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := local.NewProfileStore(local.Config{Dir: dir})
+	if err != nil {
+		t.Fatalf("new store: %v", err)
+	}
+
+	state := `{"cookies":[{"name":"sid","value":"1","domain":"shop.example","path":"/"}],"origins":[]}`
+	profile, err := store.SaveProfile(ctx, "shop", []byte(state))
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if profile.Name != "shop" || profile.Cookies != 1 || profile.Origins != 0 || profile.UpdatedAt.IsZero() {
+		t.Errorf("profile = %+v", profile)
+	}
+
+	// Профиль содержит сессии и доступен только владельцу
+	info, err := os.Stat(filepath.Join(dir, "profiles", "shop.json"))
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("profile file mode = %v", perm)
+	}
+
+	for name, state := range map[string]string{
+		"../shop": state,
+		"":        state,
+		"broken":  `{"cookies": 1}`,
+		"array":   `[]`,
+	} {
+		if _, err := store.SaveProfile(ctx, name, []byte(state)); err == nil {
+			t.Errorf("profile %q with %s saved", name, state)
+		}
+	}
+
+	if _, err := store.SaveProfile(ctx, "admin", []byte(`{}`)); err != nil {
+		t.Fatalf("save empty: %v", err)
+	}
+
+	profiles, err := store.ListProfiles(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "admin" || profiles[1].Name != "shop" {
+		t.Errorf("profiles = %+v", profiles)
+	}
+
+	loaded, data, err := store.LoadProfile(ctx, "shop")
+	if err != nil || string(data) != state || loaded.Version != profile.Version || loaded.Version == "" {
+		t.Errorf("load = %+v %s, %v", loaded, data, err)
+	}
+
+	// Задача сохраняет профиль, только если его не поменяли после её старта
+	updated := `{"cookies":[],"origins":[]}`
+	if _, err := store.UpdateProfile(ctx, "shop", []byte(updated), "stale"); !errors.Is(err, entity.ErrProfileChanged) {
+		t.Errorf("update stale: %v", err)
+	}
+	if _, err := store.UpdateProfile(ctx, "shop", []byte(updated), ""); !errors.Is(err, entity.ErrProfileChanged) {
+		t.Errorf("update as new: %v", err)
+	}
+	profile, err = store.UpdateProfile(ctx, "shop", []byte(state), loaded.Version)
+	if err != nil || profile.Version != loaded.Version {
+		t.Errorf("update same state = %+v, %v", profile, err)
+	}
+	if profile, err = store.UpdateProfile(ctx, "shop", []byte(updated), loaded.Version); err != nil || profile.Version == loaded.Version {
+		t.Errorf("update = %+v, %v", profile, err)
+	}
+	if _, err := store.UpdateProfile(ctx, "shop", []byte(state), loaded.Version); !errors.Is(err, entity.ErrProfileChanged) {
+		t.Errorf("update after another save: %v", err)
+	}
+	if _, err := store.UpdateProfile(ctx, "fresh", []byte(state), ""); err != nil {
+		t.Errorf("update new profile: %v", err)
+	}
+	if _, err := store.SaveProfile(ctx, "broken", []byte(`[]`)); !errors.Is(err, entity.ErrInvalidStorageState) {
+		t.Errorf("save broken: %v", err)
+	}
+
+	if err := store.DeleteProfile(ctx, "shop"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, _, err := store.LoadProfile(ctx, "shop"); !errors.Is(err, entity.ErrProfileNotFound) {
+		t.Errorf("load deleted: %v", err)
+	}
+	if err := store.DeleteProfile(ctx, "shop"); !errors.Is(err, entity.ErrProfileNotFound) {
+		t.Errorf("delete deleted: %v", err)
+	}
+
+	// Удалённый во время задачи профиль не восстанавливается
+	if _, err := store.UpdateProfile(ctx, "shop", []byte(state), profile.Version); !errors.Is(err, entity.ErrProfileChanged) {
+		t.Errorf("update deleted: %v", err)
+	}
+}
@@ -2,14 +2,27 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/vishenosik/ai-cherry-bro/internal/entity"
 	"github.com/vishenosik/gocherry/pkg/logs"
 )
 
+// TaskProvider постоянное хранилище задач
 type TaskProvider interface {
+	CreateTask(ctx context.Context, task entity.Task) error
+	// UpdateTask сохраняет статус и итог задачи
+	UpdateTask(ctx context.Context, task entity.Task) error
+	// AppendStep добавляет шаг и обновляет счётчик шагов и последнее действие задачи
+	AppendStep(ctx context.Context, taskID string, step entity.TaskStep) error
+	GetTask(ctx context.Context, id string) (entity.Task, error)
+	ListSteps(ctx context.Context, taskID string) ([]entity.TaskStep, error)
+	// ListTasks возвращает задачи с указанными статусами, все - если статусы не заданы
+	ListTasks(ctx context.Context, statuses ...entity.TaskStatus) ([]entity.Task, error)
 }
 
 type provider struct {
@@ -17,6 +30,19 @@ type provider struct {
 	source TaskProvider
 
 	tasksCH chan entity.PoolTask
+
+	mu sync.RWMutex
+	// tasks незавершённые задачи, завершённые читаются из source
+	tasks map[string]*taskRecord
+}
+
+// taskRecord состояние задачи и журнал её событий
+type taskRecord struct {
+	task   entity.Task
+	events []entity.TaskEvent
+	// notify закрывается и пересоздаётся при каждом новом событии
+	notify chan struct{}
+	cancel context.CancelFunc
 }
 
 func NewTaskProvider(source TaskProvider) *provider {
@@ -24,15 +50,47 @@ func NewTaskProvider(source TaskProvider) *provider {
 		source:  source,
 		log:     logs.SetupLogger().With(logs.AppComponent("usecase.task_provider")),
 		tasksCH: make(chan entity.PoolTask, 1024),
+		tasks:   make(map[string]*taskRecord),
 	}
 }
 
-func (fs *provider) NewTask(ctx context.Context, text string) (task_id string, err error) {
+func (fs *provider) NewTask(ctx context.Context, text string, opts entity.TaskOptions) (task_id string, err error) {
 	task_id = uuid.New().String()
 
+	taskCtx, cancel := context.WithCancel(context.Background())
+
+	now := time.Now()
+	task := entity.Task{
+		ID:        task_id,
+		Text:      text,
+		Options:   opts,
+		Status:    entity.TaskStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := fs.source.CreateTask(ctx, task); err != nil {
+		cancel()
+		return "", fmt.Errorf("failed to save task: %v", err)
+	}
+
+	fs.mu.Lock()
+	fs.tasks[task_id] = &taskRecord{
+		task:   task,
+		notify: make(chan struct{}),
+		cancel: cancel,
+	}
+	fs.publish(fs.tasks[task_id], entity.TaskEvent{
+		Type:   entity.TaskEventStatus,
+		Status: entity.TaskStatusQueued,
+	})
+	fs.mu.Unlock()
+
 	fs.tasksCH <- entity.PoolTask{
-		ID:   task_id,
-		Text: text,
+		ID:      task_id,
+		Text:    text,
+		Options: opts,
+		Ctx:     taskCtx,
 	}
 
 	fs.log.Info("task created",
@@ -42,6 +100,57 @@ func (fs *provider) NewTask(ctx context.Context, text string) (task_id string, e
 	return task_id, nil
 }
 
+func (fs *provider) GetTask(ctx context.Context, task_id string) (entity.Task, error) {
+	fs.mu.RLock()
+	rec, ok := fs.tasks[task_id]
+	if ok {
+		task := rec.task
+		fs.mu.RUnlock()
+		return task, nil
+	}
+	fs.mu.RUnlock()
+
+	return fs.source.GetTask(ctx, task_id)
+}
+
 func (fs *provider) TasksChan() chan entity.PoolTask {
 	return fs.tasksCH
 }
+
+// CancelTask отменяет контекст задачи. Задача из очереди отменяется сразу,
+// выполняющуюся оркестратор завершит при ближайшей проверке контекста.
+func (fs *provider) CancelTask(ctx context.Context, task_id string) (entity.Task, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	rec, ok := fs.tasks[task_id]
+	if !ok {
+		task, err := fs.source.GetTask(ctx, task_id)
+		if err != nil {
+			return entity.Task{}, err
+		}
+		return task, entity.ErrTaskFinished
+	}
+
+	rec.cancel()
+
+	if rec.task.Status == entity.TaskStatusQueued {
+		result := entity.TaskResult{
+			Status: entity.TaskStatusCancelled,
+			Error:  "task cancelled",
+		}
+		rec.task.Status = result.Status
+		rec.task.Error = result.Error
+		rec.task.UpdatedAt = time.Now()
+		fs.save(rec)
+		fs.publish(rec, entity.TaskEvent{
+			Type:   entity.TaskEventStatus,
+			Status: result.Status,
+			Result: &result,
+		})
+		fs.forget(rec)
+	}
+
+	fs.log.Info("task cancel requested", slog.String("id", task_id))
+	return rec.task, nil
+}
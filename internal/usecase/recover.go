@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// maxRestarts после стольких прерываний задача считается проваленной,
+// чтобы задача, роняющая агент, не перезапускалась бесконечно
+const maxRestarts = 3
+
+// Recover загружает незавершённые задачи из хранилища при старте. Задачи из очереди
+// ставятся в неё снова, прерванные на выполнении - тоже, с увеличенным счётчиком Restarts
+// и продолжением нумерации шагов. Завершённые задачи остаются в хранилище.
+func (fs *provider) Recover(ctx context.Context) error {
+	tasks, err := fs.source.ListTasks(ctx, entity.TaskStatusQueued, entity.TaskStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %v", err)
+	}
+
+	var requeue []entity.PoolTask
+
+	fs.mu.Lock()
+	for _, task := range tasks {
+		steps, err := fs.source.ListSteps(ctx, task.ID)
+		if err != nil {
+			fs.mu.Unlock()
+			return fmt.Errorf("failed to load task steps: %v", err)
+		}
+
+		taskCtx, cancel := context.WithCancel(context.Background())
+		rec := &taskRecord{
+			task:   task,
+			notify: make(chan struct{}),
+			cancel: cancel,
+		}
+		fs.replay(rec, steps)
+		fs.tasks[task.ID] = rec
+
+		switch task.Status {
+		case entity.TaskStatusRunning:
+			fs.interrupted(rec)
+		case entity.TaskStatusQueued:
+			if task.Options.Browser.HasSecrets() {
+				fs.abandon(rec, errSecretsNotStored)
+			}
+		}
+
+		if rec.task.Status == entity.TaskStatusQueued {
+			requeue = append(requeue, entity.PoolTask{
+				ID:      task.ID,
+				Text:    task.Text,
+				Options: task.Options,
+				Steps:   rec.task.Steps,
+				Ctx:     taskCtx,
+			})
+		}
+	}
+	fs.mu.Unlock()
+
+	// Очередь ограничена, не блокируем старт приложения
+	go func() {
+		for _, task := range requeue {
+			fs.tasksCH <- task
+		}
+	}()
+
+	fs.log.Info("tasks recovered",
+		slog.Int("unfinished", len(tasks)),
+		slog.Int("requeued", len(requeue)),
+	)
+	return nil
+}
+
+// replay восстанавливает журнал событий по сохранённому состоянию задачи
+func (fs *provider) replay(rec *taskRecord, steps []entity.TaskStep) {
+	task := rec.task
+
+	fs.publish(rec, entity.TaskEvent{
+		Type:   entity.TaskEventStatus,
+		Status: entity.TaskStatusQueued,
+	})
+	if task.Status == entity.TaskStatusQueued {
+		return
+	}
+
+	if len(steps) > 0 || task.Status == entity.TaskStatusRunning {
+		fs.publish(rec, entity.TaskEvent{
+			Type:   entity.TaskEventStatus,
+			Status: entity.TaskStatusRunning,
+		})
+	}
+	for _, step := range steps {
+		fs.publish(rec, entity.TaskEvent{
+			Type: entity.TaskEventStep,
+			Step: &step,
+		})
+	}
+
+	if task.Status.IsTerminal() {
+		fs.publish(rec, entity.TaskEvent{
+			Type:   entity.TaskEventStatus,
+			Status: task.Status,
+			Result: &entity.TaskResult{
+				Status:    task.Status,
+				Answer:    task.Answer,
+				Reasoning: task.Reasoning,
+				Error:     task.Error,
+				Data:      task.Result,
+			},
+		})
+	}
+}
+
+// errSecretsNotStored хранилище не сохраняет заголовки и пароль прокси задачи,
+// без них задача ушла бы на сайт без авторизации
+const errSecretsNotStored = "browser headers and proxy password are not stored, submit the task again"
+
+// interrupted возвращает в очередь задачу, выполнение которой прервал перезапуск.
+// Вызывается под fs.mu.
+func (fs *provider) interrupted(rec *taskRecord) {
+	rec.task.Restarts++
+	rec.task.UpdatedAt = time.Now()
+
+	if rec.task.Restarts > maxRestarts {
+		fs.abandon(rec, fmt.Sprintf("interrupted by agent restart %d times", rec.task.Restarts))
+		return
+	}
+	if rec.task.Options.Browser.HasSecrets() {
+		fs.abandon(rec, errSecretsNotStored)
+		return
+	}
+
+	rec.task.Status = entity.TaskStatusQueued
+
+	fs.log.Warn("interrupted task requeued",
+		slog.String("id", rec.task.ID),
+		slog.Int("restarts", rec.task.Restarts),
+	)
+	fs.save(rec)
+	fs.publish(rec, entity.TaskEvent{
+		Type:   entity.TaskEventStatus,
+		Status: entity.TaskStatusQueued,
+	})
+}
+
+// abandon завершает ошибкой задачу, которую нельзя продолжить после перезапуска.
+// Вызывается под fs.mu.
+func (fs *provider) abandon(rec *taskRecord, reason string) {
+	result := entity.TaskResult{
+		Status: entity.TaskStatusFailed,
+		Error:  reason,
+	}
+	rec.task.Status = result.Status
+	rec.task.Error = result.Error
+	rec.task.UpdatedAt = time.Now()
+	rec.cancel()
+
+	fs.log.Warn("recovered task failed",
+		slog.String("id", rec.task.ID),
+		slog.Int("restarts", rec.task.Restarts),
+		slog.String("error", reason),
+	)
+	fs.save(rec)
+	fs.publish(rec, entity.TaskEvent{
+		Type:   entity.TaskEventStatus,
+		Status: result.Status,
+		Result: &result,
+	})
+	fs.forget(rec)
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+	"github.com/vishenosik/gocherry/pkg/logs"
+)
+
+// Реализация core.TaskTracker: оркестратор сообщает о ходе выполнения задач
+
+func (fs *provider) TaskStarted(id string) {
+	fs.update(id, func(rec *taskRecord) {
+		rec.task.Status = entity.TaskStatusRunning
+		fs.save(rec)
+		fs.publish(rec, entity.TaskEvent{
+			Type:   entity.TaskEventStatus,
+			Status: entity.TaskStatusRunning,
+		})
+	})
+}
+
+func (fs *provider) TaskStep(id string, step entity.TaskStep) {
+	fs.update(id, func(rec *taskRecord) {
+		rec.task.Steps = step.Number
+		rec.task.LastAction = &step.Action
+		// Промежуточный результат доступен до завершения задачи
+		if step.Action.Action == "extract" && step.Error == "" {
+			rec.task.Result = step.Action.Data
+		}
+		fs.saveStep(rec, step)
+		fs.publish(rec, entity.TaskEvent{
+			Type: entity.TaskEventStep,
+			Step: &step,
+		})
+	})
+}
+
+func (fs *provider) TaskFinished(id string, result entity.TaskResult) {
+	fs.update(id, func(rec *taskRecord) {
+		rec.task.Status = result.Status
+		rec.task.Answer = result.Answer
+		rec.task.Reasoning = result.Reasoning
+		rec.task.Error = result.Error
+		if result.Data != nil {
+			rec.task.Result = result.Data
+		}
+		fs.save(rec)
+		fs.publish(rec, entity.TaskEvent{
+			Type:   entity.TaskEventStatus,
+			Status: result.Status,
+			Result: &result,
+		})
+		rec.cancel()
+		fs.forget(rec)
+	})
+
+	fs.log.Info("task finished",
+		slog.String("id", id),
+		slog.String("status", string(result.Status)),
+	)
+}
+
+func (fs *provider) update(id string, fn func(rec *taskRecord)) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	// Завершённой задачи (например, отменённой в очереди) в памяти уже нет
+	rec, ok := fs.tasks[id]
+	if !ok {
+		fs.log.Warn("unknown or finished task", slog.String("id", id))
+		return
+	}
+	rec.task.UpdatedAt = time.Now()
+	fn(rec)
+}
+
+// forget убирает завершённую задачу из памяти, дальше она читается из хранилища.
+// Подписчики WatchTask держат запись и дочитывают её события.
+// Вызывается под fs.mu после save.
+func (fs *provider) forget(rec *taskRecord) {
+	delete(fs.tasks, rec.task.ID)
+}
+
+// save сохраняет задачу в хранилище, вызывается под fs.mu.
+// Ошибка хранилища не останавливает выполнение задачи.
+func (fs *provider) save(rec *taskRecord) {
+	if err := fs.source.UpdateTask(context.Background(), rec.task); err != nil {
+		fs.log.Error("failed to save task", slog.String("id", rec.task.ID), logs.Error(err))
+	}
+}
+
+func (fs *provider) saveStep(rec *taskRecord, step entity.TaskStep) {
+	if err := fs.source.AppendStep(context.Background(), rec.task.ID, step); err != nil {
+		fs.log.Error("failed to save task step", slog.String("id", rec.task.ID), logs.Error(err))
+	}
+}
+
+// publish добавляет событие в журнал задачи и будит подписчиков.
+// Вызывается под fs.mu.
+func (fs *provider) publish(rec *taskRecord, event entity.TaskEvent) {
+	event.TaskID = rec.task.ID
+	event.Seq = len(rec.events) + 1
+	event.Time = time.Now()
+
+	rec.events = append(rec.events, event)
+
+	close(rec.notify)
+	rec.notify = make(chan struct{})
+}
+
+// ApprovalChanged реализует security.ApprovalNotifier
+func (fs *provider) ApprovalChanged(approval entity.Approval) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	rec, ok := fs.tasks[approval.TaskID]
+	if !ok {
+		return
+	}
+	fs.publish(rec, entity.TaskEvent{
+		Type:     entity.TaskEventApproval,
+		Status:   rec.task.Status,
+		Approval: &approval,
+	})
+}
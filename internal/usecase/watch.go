@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/vishenosik/ai-cherry-bro/internal/entity"
+)
+
+// WatchTask передаёт в fn все события задачи: сначала уже произошедшие,
+// затем новые по мере появления. Завершается после терминального статуса,
+// отмены ctx или ошибки fn.
+func (fs *provider) WatchTask(ctx context.Context, task_id string, fn func(entity.TaskEvent) error) error {
+	fs.mu.RLock()
+	rec, ok := fs.tasks[task_id]
+	fs.mu.RUnlock()
+
+	// Завершённая задача: журнал восстанавливается из хранилища, новых событий не будет
+	if !ok {
+		rec, err := fs.stored(ctx, task_id)
+		if err != nil {
+			return err
+		}
+		for _, event := range rec.events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Запись остаётся у подписчика и после того, как завершённая задача ушла из памяти
+	next := 0
+	for {
+		fs.mu.RLock()
+		events := rec.events[next:]
+		notify := rec.notify
+		finished := rec.task.Status.IsTerminal()
+		fs.mu.RUnlock()
+
+		for _, event := range events {
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		next += len(events)
+
+		if finished {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
+
+// stored запись задачи из хранилища с журналом, восстановленным по шагам
+func (fs *provider) stored(ctx context.Context, task_id string) (*taskRecord, error) {
+	task, err := fs.source.GetTask(ctx, task_id)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := fs.source.ListSteps(ctx, task_id)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &taskRecord{
+		task:   task,
+		notify: make(chan struct{}),
+		cancel: func() {},
+	}
+	fs.replay(rec, steps)
+	return rec, nil
+}